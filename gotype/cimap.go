@@ -0,0 +1,291 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dvln/cast"
+)
+
+// KeyCollisionError is returned when two keys of a map only differ in
+// case, Path is the dotted path to the map holding them ("" at the top)
+type KeyCollisionError struct {
+	Path     string
+	Key      string
+	Existing string
+}
+
+func (e *KeyCollisionError) Error() string {
+	where := ""
+	if e.Path != "" {
+		where = " in '" + e.Path + "'"
+	}
+	if e.Key == e.Existing {
+		return fmt.Sprintf("key '%s' is given more than once (as different types)%s", e.Key, where)
+	}
+	return fmt.Sprintf("keys '%s' and '%s' differ only in case%s", e.Existing, e.Key, where)
+}
+
+// CIMap is a map with case insensitive string keys that remembers the
+// spelling each key was first given in (for output).  Nested maps
+// (map[string]interface{}, map[interface{}]interface{} as yaml.v2 gives,
+// or any other map type) are turned into *CIMap values, including those
+// inside []interface{} slices, and JSON/YAML marshaling writes a plain
+// map with the original keys.  Non-string keys in those (ie: YAML's
+// "1: x") use their string form, so 1 and "1" in the same map collide.
+// The zero value is an empty map ready to use, or create one with
+// NewCIMap() or FromMap().
+type CIMap struct {
+	keys   map[string]string      // lower case key -> original spelling
+	values map[string]interface{} // lower case key -> value
+}
+
+// NewCIMap returns an empty case insensitive map
+func NewCIMap() *CIMap {
+	return &CIMap{keys: map[string]string{}, values: map[string]interface{}{}}
+}
+
+// FromMap builds a case insensitive map from a regular one, recursing
+// through nested maps and slices.  It fails with a *KeyCollisionError if
+// two keys (at any level) only differ in case.
+func FromMap(m map[string]interface{}) (*CIMap, error) {
+	return ciMapFrom(m, "")
+}
+
+// ciMapFrom is FromMap() keeping track of the path for errors, keys are
+// added in sorted order so the reported collision doesn't depend on map
+// iteration order
+func ciMapFrom(m map[string]interface{}, path string) (*CIMap, error) {
+	c := NewCIMap()
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lower := strings.ToLower(key)
+		if existing, ok := c.keys[lower]; ok {
+			return nil, &KeyCollisionError{Path: path, Key: key, Existing: existing}
+		}
+		val, err := ciConvert(m[key], joinPath(path, key))
+		if err != nil {
+			return nil, err
+		}
+		c.keys[lower] = key
+		c.values[lower] = val
+	}
+	return c, nil
+}
+
+// joinPath adds a key to a dotted path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// ciConvert turns nested maps (including those in slices) into *CIMap
+// values, anything else is returned as is
+func ciConvert(val interface{}, path string) (interface{}, error) {
+	switch v := val.(type) {
+	case nil, *CIMap:
+		return v, nil
+	case map[string]interface{}:
+		return ciMapFrom(v, path)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			conv, err := ciConvert(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = conv
+		}
+		return out, nil
+	}
+	// any other map (map[interface{}]interface{} from yaml.v2,
+	// map[string]string, ...) and slices of maps
+	rv := reflect.ValueOf(val)
+	switch {
+	case rv.Kind() == reflect.Map:
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := cast.ToString(iter.Key().Interface())
+			if _, dup := m[key]; dup {
+				return nil, &KeyCollisionError{Path: path, Key: key, Existing: key}
+			}
+			m[key] = iter.Value().Interface()
+		}
+		return ciMapFrom(m, path)
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Map:
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = rv.Index(i).Interface()
+		}
+		return ciConvert(items, path)
+	}
+	return val, nil
+}
+
+// Get returns the value for the key in any case
+func (c *CIMap) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	val, ok := c.values[strings.ToLower(key)]
+	return val, ok
+}
+
+// Has checks if the key is there in any case
+func (c *CIMap) Has(key string) bool {
+	_, ok := c.Get(key)
+	return ok
+}
+
+// Key returns the original spelling of the key
+func (c *CIMap) Key(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	orig, ok := c.keys[strings.ToLower(key)]
+	return orig, ok
+}
+
+// Set stores a value, an existing key keeps its original spelling, nested
+// maps are converted as FromMap() does (which is the only way this fails)
+func (c *CIMap) Set(key string, val interface{}) error {
+	conv, err := ciConvert(val, key)
+	if err != nil {
+		return err
+	}
+	if c.keys == nil {
+		*c = *NewCIMap()
+	}
+	lower := strings.ToLower(key)
+	if _, ok := c.keys[lower]; !ok {
+		c.keys[lower] = key
+	}
+	c.values[lower] = conv
+	return nil
+}
+
+// Delete removes the key in any case
+func (c *CIMap) Delete(key string) {
+	if c == nil {
+		return
+	}
+	lower := strings.ToLower(key)
+	delete(c.keys, lower)
+	delete(c.values, lower)
+}
+
+// Len returns the number of keys
+func (c *CIMap) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.keys)
+}
+
+// Keys returns the keys in their original spelling, sorted
+func (c *CIMap) Keys() []string {
+	if c == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(c.keys))
+	for _, key := range c.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ToMap returns a regular map using the original key spelling, nested
+// *CIMap values (including those in slices) are converted as well
+func (c *CIMap) ToMap() map[string]interface{} {
+	if c == nil {
+		return nil
+	}
+	m := make(map[string]interface{}, len(c.keys))
+	for lower, key := range c.keys {
+		m[key] = ciUnconvert(c.values[lower])
+	}
+	return m
+}
+
+// ciUnconvert turns nested *CIMap values back into regular maps
+func ciUnconvert(val interface{}) interface{} {
+	switch v := val.(type) {
+	case *CIMap:
+		return v.ToMap()
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = ciUnconvert(item)
+		}
+		return out
+	}
+	return val
+}
+
+// MarshalJSON writes the map as a JSON object with the original keys, it
+// has a value receiver so a CIMap held by value in a struct works too
+func (c CIMap) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.ToMap())
+}
+
+// UnmarshalJSON reads a JSON object, failing on keys that differ only in
+// case
+func (c *CIMap) UnmarshalJSON(data []byte) error {
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	conv, err := FromMap(m)
+	if err != nil {
+		return err
+	}
+	*c = *conv
+	return nil
+}
+
+// MarshalYAML writes the map with the original keys (value receiver for
+// the same reason as MarshalJSON)
+func (c CIMap) MarshalYAML() (interface{}, error) {
+	return c.ToMap(), nil
+}
+
+// UnmarshalYAML reads a YAML mapping, failing on keys that differ only in
+// case (this is the yaml.v2 style interface which yaml.v3 also honors)
+func (c *CIMap) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var m map[string]interface{}
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+	conv, err := FromMap(m)
+	if err != nil {
+		return err
+	}
+	*c = *conv
+	return nil
+}
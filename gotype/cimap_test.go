@@ -0,0 +1,175 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestCIMap(t *testing.T) {
+	c, err := FromMap(map[string]interface{}{
+		"Name": "dvln",
+		"Build": map[string]interface{}{
+			"GoVersion": "1.21",
+		},
+		"Targets": []interface{}{
+			map[interface{}]interface{}{"OS": "linux", "Arch": "amd64"},
+			"plain",
+		},
+		"Labels": map[string]string{"Team": "tools"},
+	})
+	if err != nil {
+		t.Fatalf("FromMap failed: %s", err)
+	}
+	val, ok := c.Get("NAME")
+	checkEqual(t, "Get", "dvln", val)
+	checkEqual(t, "Get ok", true, ok)
+	checkEqual(t, "Has", false, c.Has("missing"))
+	key, _ := c.Key("name")
+	checkEqual(t, "Key", "Name", key)
+	checkEqual(t, "Keys", []string{"Build", "Labels", "Name", "Targets"}, c.Keys())
+
+	build, _ := c.Get("build")
+	ver, _ := build.(*CIMap).Get("goversion")
+	checkEqual(t, "nested Get", "1.21", ver)
+	targets, _ := c.Get("targets")
+	arch, _ := targets.([]interface{})[0].(*CIMap).Get("arch")
+	checkEqual(t, "Get in slice", "amd64", arch)
+	labels, _ := c.Get("labels")
+	team, _ := labels.(*CIMap).Get("TEAM")
+	checkEqual(t, "Get in map[string]string", "tools", team)
+
+	// setting an existing key keeps its spelling
+	c.Set("NAME", "other")
+	checkEqual(t, "Set", []string{"Build", "Labels", "Name", "Targets"}, c.Keys())
+	c.Delete("labels")
+	c.Delete("TARGETS")
+	checkEqual(t, "Delete", 2, c.Len())
+
+	checkEqual(t, "ToMap", map[string]interface{}{
+		"Name":  "other",
+		"Build": map[string]interface{}{"GoVersion": "1.21"},
+	}, c.ToMap())
+
+	var zero CIMap
+	if err := zero.Set("Key", 1); err != nil {
+		t.Fatalf("Set on the zero value failed: %s", err)
+	}
+	checkEqual(t, "zero value", true, zero.Has("KEY"))
+
+	// a nil map reads as empty and Delete is a no-op
+	var none *CIMap
+	none.Delete("key")
+	checkEqual(t, "nil Len", 0, none.Len())
+}
+
+func TestCIMapCollision(t *testing.T) {
+	_, err := FromMap(map[string]interface{}{
+		"top": map[string]interface{}{"Key": 1, "KEY": 2},
+	})
+	var collision *KeyCollisionError
+	if !errors.As(err, &collision) {
+		t.Fatalf("expected a KeyCollisionError, got %v", err)
+	}
+	checkEqual(t, "collision", KeyCollisionError{Path: "top", Key: "Key", Existing: "KEY"}, *collision)
+	checkEqual(t, "collision message", "keys 'KEY' and 'Key' differ only in case in 'top'", err.Error())
+
+	_, err = FromMap(map[string]interface{}{
+		"list": []interface{}{map[string]interface{}{"a": 1, "A": 2}},
+	})
+	if !errors.As(err, &collision) || collision.Path != "list[0]" {
+		t.Fatalf("expected a collision in list[0], got %v", err)
+	}
+
+	c := NewCIMap()
+	if err := c.Set("x", map[string]interface{}{"b": 1, "B": 1}); err == nil {
+		t.Fatalf("expected Set to report the collision")
+	}
+	checkEqual(t, "failed Set", false, c.Has("x"))
+
+	// non-string keys use their string form, so 1 and "1" collide
+	_, err = FromMap(map[string]interface{}{
+		"ids": map[interface{}]interface{}{1: "a", "1": "b"},
+	})
+	if !errors.As(err, &collision) || collision.Key != "1" || collision.Path != "ids" {
+		t.Fatalf("expected a collision between 1 and \"1\" in ids, got %v", err)
+	}
+	checkEqual(t, "type collision message", "key '1' is given more than once (as different types) in 'ids'", err.Error())
+}
+
+func TestCIMapJSON(t *testing.T) {
+	var cfg struct {
+		Settings *CIMap `json:"settings"`
+	}
+	in := `{"settings":{"LogLevel":"debug","Paths":[{"Root":"/src"}]}}`
+	if err := json.Unmarshal([]byte(in), &cfg); err != nil {
+		t.Fatalf("unmarshal failed: %s", err)
+	}
+	level, _ := cfg.Settings.Get("loglevel")
+	checkEqual(t, "Get", "debug", level)
+
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshal failed: %s", err)
+	}
+	checkEqual(t, "round trip", in, string(out))
+
+	if err := json.Unmarshal([]byte(`{"settings":{"a":1,"A":2}}`), &cfg); err == nil {
+		t.Fatalf("expected a collision error from UnmarshalJSON")
+	}
+
+	// held by value rather than pointer
+	var byValue struct {
+		Settings CIMap `json:"settings"`
+	}
+	byValue.Settings.Set("LogLevel", "info")
+	out, err = json.Marshal(byValue)
+	if err != nil {
+		t.Fatalf("marshal failed: %s", err)
+	}
+	checkEqual(t, "by value", `{"settings":{"LogLevel":"info"}}`, string(out))
+	var empty CIMap
+	out, _ = json.Marshal(empty)
+	checkEqual(t, "empty", `{}`, string(out))
+}
+
+func TestCIMapYAML(t *testing.T) {
+	// fake the yaml.v2 unmarshal callback, nested maps come through as
+	// map[interface{}]interface{}
+	unmarshal := func(v interface{}) error {
+		*(v.(*map[string]interface{})) = map[string]interface{}{
+			"Server": map[interface{}]interface{}{"Port": 8080},
+		}
+		return nil
+	}
+	var c CIMap
+	if err := c.UnmarshalYAML(unmarshal); err != nil {
+		t.Fatalf("UnmarshalYAML failed: %s", err)
+	}
+	server, _ := c.Get("server")
+	port, _ := server.(*CIMap).Get("PORT")
+	checkEqual(t, "Get", 8080, port)
+
+	out, err := c.MarshalYAML()
+	if err != nil {
+		t.Fatalf("MarshalYAML failed: %s", err)
+	}
+	checkEqual(t, "MarshalYAML", map[string]interface{}{
+		"Server": map[string]interface{}{"Port": 8080},
+	}, out)
+}
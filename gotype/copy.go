@@ -0,0 +1,329 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// copyKey identifies an already copied pointer, map or slice (by address,
+// length for slices, and type) so shared and cyclic references come out
+// the same in the copy
+type copyKey struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+// DeepCopy returns a deep copy of v, maps, slices, pointers and structs
+// are copied all the way down (ie: a config tree of map[string]interface{}
+// and []interface{} values, or a struct holding pointers).  Values shared
+// within v stay shared in the copy and cycles are preserved rather than
+// followed forever.  Unexported struct fields, channels and funcs are
+// copied shallowly as they can't be reached by reflection.
+func DeepCopy[T any](v T) T {
+	rv := reflect.ValueOf(&v).Elem()
+	out := reflect.New(rv.Type()).Elem()
+	c := &copier{seen: map[copyKey]reflect.Value{}}
+	c.copy(out, rv)
+	return *out.Addr().Interface().(*T)
+}
+
+type copier struct {
+	seen map[copyKey]reflect.Value
+}
+
+// copy deep copies src into dst, which is a settable zero value of the
+// same type
+func (c *copier) copy(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		elem := reflect.New(src.Elem().Type()).Elem()
+		c.copy(elem, src.Elem())
+		dst.Set(elem)
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		key := copyKey{src.Pointer(), 0, src.Type()}
+		if done, ok := c.seen[key]; ok {
+			dst.Set(done)
+			return
+		}
+		ptr := reflect.New(src.Type().Elem())
+		c.seen[key] = ptr
+		c.copy(ptr.Elem(), src.Elem())
+		dst.Set(ptr)
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		key := copyKey{src.Pointer(), 0, src.Type()}
+		if done, ok := c.seen[key]; ok {
+			dst.Set(done)
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		c.seen[key] = m
+		iter := src.MapRange()
+		for iter.Next() {
+			k := reflect.New(iter.Key().Type()).Elem()
+			c.copy(k, iter.Key())
+			val := reflect.New(iter.Value().Type()).Elem()
+			c.copy(val, iter.Value())
+			m.SetMapIndex(k, val)
+		}
+		dst.Set(m)
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		key := copyKey{src.Pointer(), src.Len(), src.Type()}
+		if done, ok := c.seen[key]; ok {
+			dst.Set(done)
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Cap())
+		c.seen[key] = s
+		for i := 0; i < src.Len(); i++ {
+			c.copy(s.Index(i), src.Index(i))
+		}
+		dst.Set(s)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			c.copy(dst.Index(i), src.Index(i))
+		}
+	case reflect.Struct:
+		// start from a shallow copy so unexported fields come along
+		dst.Set(src)
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				field := reflect.New(src.Field(i).Type()).Elem()
+				c.copy(field, src.Field(i))
+				dst.Field(i).Set(field)
+			}
+		}
+	default:
+		dst.Set(src)
+	}
+}
+
+// EqualOptions loosens the comparison done by Equal, the zero value is
+// as strict as reflect.DeepEqual
+type EqualOptions struct {
+	// Numeric compares numbers by value whatever their type, so int(1)
+	// equals float64(1) as JSON decoding gives
+	Numeric bool
+	// CaseInsensitiveKeys matches string map keys ignoring case
+	CaseInsensitiveKeys bool
+	// NilEmpty treats nil, empty maps, empty slices and "" as equal, and
+	// a missing map key as equal to one holding any of those
+	NilEmpty bool
+}
+
+// visitKey identifies a pair of references already being compared
+type visitKey struct {
+	a, b uintptr
+	typ  reflect.Type
+}
+
+// Equal compares two values deeply (maps, slices, pointers and structs
+// all the way down) with the given tolerances, cycles are handled
+func Equal(a, b interface{}, opts EqualOptions) bool {
+	e := &equaler{opts: opts, seen: map[visitKey]bool{}}
+	return e.equal(reflect.ValueOf(a), reflect.ValueOf(b))
+}
+
+type equaler struct {
+	opts EqualOptions
+	seen map[visitKey]bool
+}
+
+// isNilOrEmpty checks for the values NilEmpty treats as equal
+func isNilOrEmpty(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil() || (v.Kind() == reflect.Interface && isNilOrEmpty(v.Elem()))
+	case reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+	return false
+}
+
+// unwrap strips interfaces off a value
+func unwrap(v reflect.Value) reflect.Value {
+	for v.IsValid() && v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// numberKind returns 'i', 'u' or 'f' for numeric kinds, 0 for others
+func numberKind(k reflect.Kind) byte {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return 'i'
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return 'u'
+	case reflect.Float32, reflect.Float64:
+		return 'f'
+	}
+	return 0
+}
+
+// numbersEqual compares two numbers of any type by value, without
+// going through float64 when both are integers
+func numbersEqual(a, b reflect.Value) bool {
+	ak, bk := numberKind(a.Kind()), numberKind(b.Kind())
+	switch {
+	case ak == 'i' && bk == 'i':
+		return a.Int() == b.Int()
+	case ak == 'u' && bk == 'u':
+		return a.Uint() == b.Uint()
+	case ak == 'i' && bk == 'u':
+		return a.Int() >= 0 && uint64(a.Int()) == b.Uint()
+	case ak == 'u' && bk == 'i':
+		return b.Int() >= 0 && uint64(b.Int()) == a.Uint()
+	}
+	return toFloatValue(a) == toFloatValue(b)
+}
+
+// toFloatValue returns any numeric value as a float64
+func toFloatValue(v reflect.Value) float64 {
+	switch numberKind(v.Kind()) {
+	case 'i':
+		return float64(v.Int())
+	case 'u':
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
+
+func (e *equaler) equal(a, b reflect.Value) bool {
+	a, b = unwrap(a), unwrap(b)
+	if e.opts.NilEmpty && isNilOrEmpty(a) && isNilOrEmpty(b) {
+		return true
+	}
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	if e.opts.Numeric && numberKind(a.Kind()) != 0 && numberKind(b.Kind()) != 0 {
+		return numbersEqual(a, b)
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		if a.Pointer() == b.Pointer() && (a.Kind() != reflect.Slice || a.Len() == b.Len()) {
+			return true
+		}
+		key := visitKey{a.Pointer(), b.Pointer(), a.Type()}
+		if e.seen[key] {
+			// already comparing these further up, assume equal
+			return true
+		}
+		e.seen[key] = true
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return e.equal(a.Elem(), b.Elem())
+	case reflect.Map:
+		return e.mapsEqual(a, b)
+	case reflect.Slice, reflect.Array:
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !e.equal(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Struct:
+		if a.Type() == timeType && a.CanInterface() {
+			return a.Interface().(time.Time).Equal(b.Interface().(time.Time))
+		}
+		for i := 0; i < a.NumField(); i++ {
+			if !e.equal(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Bool:
+		return a.Bool() == b.Bool()
+	case reflect.String:
+		return a.String() == b.String()
+	case reflect.Complex64, reflect.Complex128:
+		return a.Complex() == b.Complex()
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return a.Pointer() == b.Pointer() && (a.Kind() != reflect.Func || a.IsNil())
+	}
+	if numberKind(a.Kind()) != 0 {
+		return numbersEqual(a, b)
+	}
+	return false
+}
+
+// mapsEqual compares two maps of the same type
+func (e *equaler) mapsEqual(a, b reflect.Value) bool {
+	fold := e.opts.CaseInsensitiveKeys && a.Type().Key().Kind() == reflect.String
+	if !fold && !e.opts.NilEmpty && a.Len() != b.Len() {
+		return false
+	}
+	lookup := func(m reflect.Value, key reflect.Value) reflect.Value {
+		if val := m.MapIndex(key); val.IsValid() || !fold {
+			return val
+		}
+		iter := m.MapRange()
+		for iter.Next() {
+			if strings.EqualFold(iter.Key().String(), key.String()) {
+				return iter.Value()
+			}
+		}
+		return reflect.Value{}
+	}
+	// every key of a must match in b and every key of b must be in a
+	for _, pair := range [][2]reflect.Value{{a, b}, {b, a}} {
+		iter := pair[0].MapRange()
+		for iter.Next() {
+			other := lookup(pair[1], iter.Key())
+			if !other.IsValid() {
+				if e.opts.NilEmpty && isNilOrEmpty(iter.Value()) {
+					continue
+				}
+				return false
+			}
+			if !e.equal(iter.Value(), other) {
+				return false
+			}
+		}
+	}
+	return true
+}
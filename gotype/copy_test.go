@@ -0,0 +1,136 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type copyNode struct {
+	Name     string
+	Children []*copyNode
+	Parent   *copyNode
+	Attrs    map[string]interface{}
+	secret   string
+}
+
+func TestDeepCopyMap(t *testing.T) {
+	orig := map[string]interface{}{
+		"log":   map[string]interface{}{"level": "info"},
+		"paths": []interface{}{"/a", map[string]interface{}{"b": 1}},
+		"n":     nil,
+	}
+	cp := DeepCopy(orig)
+	checkEqual(t, "DeepCopy", orig, cp)
+	cp["log"].(map[string]interface{})["level"] = "debug"
+	cp["paths"].([]interface{})[1].(map[string]interface{})["b"] = 2
+	checkEqual(t, "orig level", "info", orig["log"].(map[string]interface{})["level"])
+	checkEqual(t, "orig nested", 1, orig["paths"].([]interface{})[1].(map[string]interface{})["b"])
+
+	// the usual reason to copy, InsensitiviseMap changes its argument
+	InsensitiviseMap(DeepCopy(map[string]interface{}{"Key": 1}))
+
+	var nilMap map[string]interface{}
+	checkEqual(t, "nil map", map[string]interface{}(nil), DeepCopy(nilMap))
+	checkEqual(t, "scalar", 42, DeepCopy(42))
+}
+
+func TestDeepCopyStruct(t *testing.T) {
+	root := &copyNode{Name: "root", Attrs: map[string]interface{}{"x": []interface{}{1}}, secret: "s"}
+	child := &copyNode{Name: "child", Parent: root}
+	root.Children = []*copyNode{child, child}
+
+	cp := DeepCopy(root)
+	if cp == root || cp.Children[0] == child {
+		t.Fatalf("expected new pointers in the copy")
+	}
+	if cp.Children[0].Parent != cp {
+		t.Fatalf("expected the cycle to point at the copied root")
+	}
+	if cp.Children[0] != cp.Children[1] {
+		t.Fatalf("expected shared pointers to stay shared")
+	}
+	checkEqual(t, "unexported", "s", cp.secret)
+	cp.Attrs["x"].([]interface{})[0] = 2
+	checkEqual(t, "orig attrs", 1, root.Attrs["x"].([]interface{})[0])
+
+	// maps that contain themselves
+	self := map[string]interface{}{}
+	self["me"] = self
+	selfCopy := DeepCopy(self)
+	selfCopy["new"] = true
+	if _, ok := selfCopy["me"].(map[string]interface{})["new"]; !ok {
+		t.Fatalf("expected the copied map to point at itself")
+	}
+	if _, ok := self["new"]; ok {
+		t.Fatalf("the original map changed")
+	}
+
+	// slices that contain themselves
+	list := make([]interface{}, 1)
+	list[0] = list
+	listCopy := DeepCopy(list)
+	inner := listCopy[0].([]interface{})
+	if &inner[0] != &listCopy[0] {
+		t.Fatalf("expected the copied slice to point at itself")
+	}
+	if &listCopy[0] == &list[0] {
+		t.Fatalf("expected a new backing array in the copy")
+	}
+
+	// a nil interface comes back as nil rather than panicking
+	var none interface{}
+	checkEqual(t, "nil interface", nil, DeepCopy(none))
+}
+
+func TestEqual(t *testing.T) {
+	var decoded map[string]interface{}
+	json.Unmarshal([]byte(`{"Jobs": 4, "Paths": ["/a"], "Log": {"Level": "info"}, "Tags": []}`), &decoded)
+	native := map[string]interface{}{
+		"jobs":  4,
+		"paths": []interface{}{"/a"},
+		"log":   map[string]interface{}{"level": "info"},
+	}
+	checkEqual(t, "strict", false, Equal(decoded, native, EqualOptions{}))
+	checkEqual(t, "numeric only", false, Equal(decoded, native, EqualOptions{Numeric: true}))
+	loose := EqualOptions{Numeric: true, CaseInsensitiveKeys: true, NilEmpty: true}
+	checkEqual(t, "loose", true, Equal(decoded, native, loose))
+	checkEqual(t, "loose differs", false, Equal(decoded, map[string]interface{}{"jobs": 5}, loose))
+
+	checkEqual(t, "numeric", true, Equal(1, 1.0, EqualOptions{Numeric: true}))
+	checkEqual(t, "numeric uint", true, Equal(uint8(200), int64(200), EqualOptions{Numeric: true}))
+	checkEqual(t, "numeric negative", false, Equal(-1, uint(1<<64-1), EqualOptions{Numeric: true}))
+	checkEqual(t, "numeric big", false, Equal(int64(1<<62+1), int64(1<<62), EqualOptions{Numeric: true}))
+	checkEqual(t, "types", false, Equal(1, 1.0, EqualOptions{}))
+	checkEqual(t, "nil empty", true, Equal(nil, []interface{}{}, EqualOptions{NilEmpty: true}))
+	checkEqual(t, "nil empty string", true, Equal("", map[string]interface{}(nil), EqualOptions{NilEmpty: true}))
+	checkEqual(t, "nil strict", false, Equal(nil, []interface{}{}, EqualOptions{}))
+	checkEqual(t, "nil nil", true, Equal(nil, nil, EqualOptions{}))
+
+	utc := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+	checkEqual(t, "times", true, Equal(utc, utc.In(time.FixedZone("x", 3600)), EqualOptions{}))
+
+	a := &copyNode{Name: "n"}
+	a.Parent = a
+	b := &copyNode{Name: "n"}
+	b.Parent = b
+	checkEqual(t, "cycles", true, Equal(a, b, EqualOptions{}))
+	b.Name = "m"
+	checkEqual(t, "cycles differ", false, Equal(a, b, EqualOptions{}))
+	checkEqual(t, "copy equal", true, Equal(a, DeepCopy(a), EqualOptions{}))
+}
@@ -0,0 +1,556 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dvln/cast"
+	"github.com/dvln/util/units"
+)
+
+// DefaultTagName is the struct tag Decode and Encode use unless the
+// options give another, ie:
+//
+//	Jobs    int            `config:"jobs" default:"4"`
+//	Timeout time.Duration  `config:"timeout" default:"90s"`
+//	Tags    []string       `config:"tags" default:"fast,safe"`
+//	Cache   units.Size     `config:"cache_size,omitempty"`
+//	Common  `config:",squash"`
+var DefaultTagName = "config"
+
+// DecodeOptions controls Decode (and the tag name for Encode), the zero
+// value matches keys case insensitively and converts types weakly
+type DecodeOptions struct {
+	// TagName is the struct tag holding the key name, DefaultTagName if
+	// empty.  Fields without one use the field name.
+	TagName string
+	// CaseSensitive matches keys exactly rather than ignoring case
+	CaseSensitive bool
+	// Strict turns off the weak conversions (ie: "8080" for an int or 1
+	// for a bool), numbers still convert between numeric types if no
+	// precision is lost
+	Strict bool
+	// ErrorUnused reports map keys that don't match a struct field
+	ErrorUnused bool
+}
+
+func (opts DecodeOptions) tagName() string {
+	if opts.TagName == "" {
+		return DefaultTagName
+	}
+	return opts.TagName
+}
+
+// FieldError is a failure to decode one value, Path is its key path
+// (ie: "build.targets[1].arch")
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("'%s': %s", e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError holds every error found by Decode
+type DecodeError struct {
+	Errors []*FieldError
+}
+
+func (e *DecodeError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = "* " + err.Error()
+	}
+	plural := "s"
+	if len(msgs) == 1 {
+		plural = ""
+	}
+	return fmt.Sprintf("%d error%s decoding:\n%s", len(msgs), plural, strings.Join(msgs, "\n"))
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// structField is a (possibly promoted) field of a struct being decoded
+type structField struct {
+	name       string
+	index      []int
+	def        string
+	hasDefault bool
+	omitEmpty  bool
+}
+
+// structFields lists the fields of a struct type, embedded structs
+// without a key name (or tagged ",squash") have their fields promoted
+func structFields(t reflect.Type, tagName string) []structField {
+	fields := []structField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get(tagName)
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		squash, omitEmpty := false, false
+		for _, opt := range parts[1:] {
+			switch opt {
+			case "squash":
+				squash = true
+			case "omitempty":
+				omitEmpty = true
+			}
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && (squash || name == "") {
+			for _, sub := range structFields(ft, tagName) {
+				sub.index = append([]int{i}, sub.index...)
+				fields = append(fields, sub)
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		def, hasDefault := f.Tag.Lookup("default")
+		fields = append(fields, structField{name: name, index: []int{i}, def: def, hasDefault: hasDefault, omitEmpty: omitEmpty})
+	}
+	return fields
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex allocating nil embedded
+// struct pointers along the way, like encoding/json it fails if that
+// pointer is to an unexported struct type (reflect can't set it)
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// decoder collects the errors from a Decode
+type decoder struct {
+	opts DecodeOptions
+	errs []*FieldError
+}
+
+func (d *decoder) fail(path string, format string, args ...interface{}) {
+	d.errs = append(d.errs, &FieldError{Path: path, Err: fmt.Errorf(format, args...)})
+}
+
+// Decode fills the struct that out points to from the map tree, matching
+// keys to fields by their tag (see DecodeOptions and DefaultTagName).  It
+// handles nested structs, embedded structs (their fields are promoted),
+// pointers, slices, maps, "default" tags for missing keys (a comma
+// separated list for slices), time.Duration (with units.ParseDuration so
+// "3d" works), time.Time and anything with an UnmarshalText method (such
+// as units.Size, so "10GB" works).  Values are converted with cast unless
+// Strict is set.  It carries on past bad values and returns a
+// *DecodeError with every problem found.
+func Decode(m map[string]interface{}, out interface{}, opts DecodeOptions) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode target must be a non-nil pointer to a struct, not %T", out)
+	}
+	d := &decoder{opts: opts}
+	d.decodeStruct("", m, rv.Elem())
+	if len(d.errs) > 0 {
+		return &DecodeError{Errors: d.errs}
+	}
+	return nil
+}
+
+// decodeStruct fills a struct value from a map
+func (d *decoder) decodeStruct(path string, m map[string]interface{}, out reflect.Value) {
+	used := map[string]bool{}
+	for _, f := range structFields(out.Type(), d.opts.tagName()) {
+		fieldPath := joinPath(path, f.name)
+		key, ok := PathOptions{CaseInsensitive: !d.opts.CaseSensitive}.findKey(m, f.name)
+		if !ok && !f.hasDefault {
+			continue
+		}
+		val, fromTag := interface{}(f.def), true
+		if ok {
+			used[key] = true
+			val, fromTag = m[key], false
+		}
+		if val == nil {
+			continue
+		}
+		field, err := fieldByIndex(out, f.index)
+		if err != nil {
+			d.fail(fieldPath, "%s", err)
+			continue
+		}
+		d.decodeValue(fieldPath, val, field, fromTag)
+	}
+	if d.opts.ErrorUnused {
+		unused := []string{}
+		for key := range m {
+			if !used[key] {
+				unused = append(unused, key)
+			}
+		}
+		sort.Strings(unused)
+		for _, key := range unused {
+			d.fail(joinPath(path, key), "no matching field")
+		}
+	}
+}
+
+// decodeValue sets out from val, fromTag is set for default tag values
+// which are always converted weakly (they're strings)
+func (d *decoder) decodeValue(path string, val interface{}, out reflect.Value, fromTag bool) {
+	if val == nil {
+		return
+	}
+	weak := !d.opts.Strict || fromTag
+	t := out.Type()
+	if t.Kind() == reflect.Ptr {
+		if out.IsNil() {
+			out.Set(reflect.New(t.Elem()))
+		}
+		d.decodeValue(path, val, out.Elem(), fromTag)
+		return
+	}
+	if str, ok := val.(string); ok && t != durationType && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		if err := out.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(str)); err != nil {
+			d.fail(path, "%s", err)
+		}
+		return
+	}
+
+	switch {
+	case t == durationType:
+		if str, ok := val.(string); ok {
+			dur, err := units.ParseDuration(str)
+			if err != nil {
+				d.fail(path, "%s", err)
+				return
+			}
+			out.SetInt(int64(dur))
+			return
+		}
+	case t == timeType:
+		tm, err := cast.ToTimeE(val)
+		if err != nil {
+			d.fail(path, "cannot convert %s to a time", kindName(val))
+			return
+		}
+		out.Set(reflect.ValueOf(tm))
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		v := reflect.ValueOf(val)
+		if !v.Type().AssignableTo(t) {
+			d.fail(path, "cannot use %s as %s", kindName(val), t)
+			return
+		}
+		out.Set(v)
+	case reflect.Bool:
+		b, ok := val.(bool)
+		if !ok && weak {
+			var err error
+			b, err = cast.ToBoolE(val)
+			ok = err == nil
+		}
+		if !ok {
+			d.fail(path, "cannot convert %s to a bool", kindName(val))
+			return
+		}
+		out.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := d.toInt(val, weak)
+		if !ok || out.OverflowInt(n) {
+			d.fail(path, "cannot convert %s %v to %s", kindName(val), val, t)
+			return
+		}
+		out.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := d.toUint(val, weak)
+		if !ok || out.OverflowUint(n) {
+			d.fail(path, "cannot convert %s %v to %s", kindName(val), val, t)
+			return
+		}
+		out.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, ok := d.toNumber(val, weak)
+		if !ok || out.OverflowFloat(n) {
+			d.fail(path, "cannot convert %s %v to %s", kindName(val), val, t)
+			return
+		}
+		out.SetFloat(n)
+	case reflect.String:
+		str, ok := val.(string)
+		if !ok && weak {
+			var err error
+			str, err = cast.ToStringE(val)
+			ok = err == nil
+		}
+		if !ok {
+			d.fail(path, "cannot convert %s to a string", kindName(val))
+			return
+		}
+		out.SetString(str)
+	case reflect.Slice:
+		d.decodeSlice(path, val, out, weak, fromTag)
+	case reflect.Map:
+		m, ok := asStringMap(val)
+		if !ok || t.Key().Kind() != reflect.String {
+			d.fail(path, "cannot convert %s to %s", kindName(val), t)
+			return
+		}
+		if out.IsNil() {
+			out.Set(reflect.MakeMapWithSize(t, len(m)))
+		}
+		for key, item := range m {
+			elem := reflect.New(t.Elem()).Elem()
+			n := len(d.errs)
+			d.decodeValue(joinPath(path, key), item, elem, fromTag)
+			if len(d.errs) == n {
+				out.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), elem)
+			}
+		}
+	case reflect.Struct:
+		m, ok := asStringMap(val)
+		if !ok {
+			d.fail(path, "cannot convert %s to %s", kindName(val), t)
+			return
+		}
+		d.decodeStruct(path, m, out)
+	default:
+		d.fail(path, "unsupported field type %s", t)
+	}
+}
+
+// toNumber gets a float64 from any number type, or with weak conversion
+// from strings and bools too
+func (d *decoder) toNumber(val interface{}, weak bool) (float64, bool) {
+	if n, ok := toFloat(val); ok {
+		return n, true
+	}
+	if !weak {
+		return 0, false
+	}
+	n, err := cast.ToFloat64E(val)
+	return n, err == nil
+}
+
+// toInt gets an int64 from any integer type without going through
+// float64 (so big values stay exact), floats and weakly converted values
+// must be whole numbers in range
+func (d *decoder) toInt(val interface{}, weak bool) (int64, bool) {
+	rv := reflect.ValueOf(val)
+	switch numberKind(rv.Kind()) {
+	case 'i':
+		return rv.Int(), true
+	case 'u':
+		return int64(rv.Uint()), rv.Uint() <= math.MaxInt64
+	}
+	if str, ok := val.(string); ok && weak {
+		if n, err := strconv.ParseInt(strings.TrimSpace(str), 10, 64); err == nil {
+			return n, true
+		}
+	}
+	n, ok := d.toNumber(val, weak)
+	if !ok || n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(n), true
+}
+
+// toUint is toInt for unsigned integers, negative values fail
+func (d *decoder) toUint(val interface{}, weak bool) (uint64, bool) {
+	rv := reflect.ValueOf(val)
+	switch numberKind(rv.Kind()) {
+	case 'i':
+		return uint64(rv.Int()), rv.Int() >= 0
+	case 'u':
+		return rv.Uint(), true
+	}
+	if str, ok := val.(string); ok && weak {
+		if n, err := strconv.ParseUint(strings.TrimSpace(str), 10, 64); err == nil {
+			return n, true
+		}
+	}
+	n, ok := d.toNumber(val, weak)
+	if !ok || n != math.Trunc(n) || n < 0 || n >= math.MaxUint64 {
+		return 0, false
+	}
+	return uint64(n), true
+}
+
+// toFloat returns a number of any int, uint or float type as a float64
+func toFloat(val interface{}) (float64, bool) {
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// decodeSlice fills a slice from a list, a single value is treated as a
+// one item list when converting weakly and a default tag is split on
+// commas (ie: `default:"a,b"` gives two items)
+func (d *decoder) decodeSlice(path string, val interface{}, out reflect.Value, weak, fromTag bool) {
+	if str, ok := val.(string); ok && fromTag {
+		list := []interface{}{}
+		if str != "" {
+			for _, item := range strings.Split(str, ",") {
+				list = append(list, item)
+			}
+		}
+		val = list
+	}
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		if !weak {
+			d.fail(path, "cannot convert %s to %s", kindName(val), out.Type())
+			return
+		}
+		rv = reflect.ValueOf([]interface{}{val})
+	}
+	list := reflect.MakeSlice(out.Type(), rv.Len(), rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		d.decodeValue(fmt.Sprintf("%s[%d]", path, i), rv.Index(i).Interface(), list.Index(i), fromTag)
+	}
+	out.Set(list)
+}
+
+// Encode is the reverse of Decode, it turns a struct (or pointer to one)
+// into a map tree using the DefaultTagName keys, nested structs become
+// maps and lists of them []interface{} lists.  Other values (including
+// time.Duration and units.Size) are kept as they are so Decode can read
+// them back.  Fields tagged ",omitempty" are left out when zero.  It
+// returns nil for anything that isn't a struct.
+func Encode(in interface{}) map[string]interface{} {
+	return EncodeWith(in, DecodeOptions{})
+}
+
+// EncodeWith is Encode using the tag name from the options
+func EncodeWith(in interface{}, opts DecodeOptions) map[string]interface{} {
+	rv := reflect.ValueOf(in)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	m, _ := encodeValue(rv, opts.tagName()).(map[string]interface{})
+	return m
+}
+
+// encodeValue turns a value into its map tree form
+func encodeValue(v reflect.Value, tagName string) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return encodeValue(v.Elem(), tagName)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface()
+		}
+		m := map[string]interface{}{}
+		for _, f := range structFields(v.Type(), tagName) {
+			fv, ok := encodeField(v, f.index)
+			if !ok || (f.omitEmpty && fv.IsZero()) {
+				continue
+			}
+			m[f.name] = encodeValue(fv, tagName)
+		}
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = encodeValue(v.Index(i), tagName)
+		}
+		return list
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = encodeValue(iter.Value(), tagName)
+		}
+		return m
+	}
+	return v.Interface()
+}
+
+// encodeField gets a possibly promoted field, false if it's behind a nil
+// embedded pointer
+func encodeField(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,261 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dvln/util/units"
+)
+
+type decodeCommon struct {
+	Name    string `config:"name"`
+	Verbose bool   `config:"verbose"`
+}
+
+type decodeTarget struct {
+	OS   string `config:"os"`
+	Arch string `config:"arch" default:"amd64"`
+}
+
+type decodeConfig struct {
+	decodeCommon
+	Jobs     int               `config:"jobs" default:"4"`
+	Ratio    float64           `config:"ratio"`
+	Timeout  time.Duration     `config:"timeout" default:"90s"`
+	Interval units.Duration    `config:"interval"`
+	Cache    units.Size        `config:"cache_size,omitempty"`
+	Targets  []decodeTarget    `config:"targets"`
+	Tags     []string          `config:"tags"`
+	Labels   map[string]string `config:"labels"`
+	Parent   *decodeTarget     `config:"parent"`
+	Extra    interface{}       `config:"extra"`
+	Skipped  string            `config:"-"`
+	Port     uint16
+	internal string
+}
+
+func TestDecode(t *testing.T) {
+	m := map[string]interface{}{
+		"Name":       "dvln",
+		"verbose":    "true",
+		"JOBS":       "8",
+		"ratio":      1,
+		"timeout":    "3d",
+		"interval":   "1h30m",
+		"cache_size": "10GiB",
+		"targets": []interface{}{
+			map[string]interface{}{"os": "linux"},
+			map[interface{}]interface{}{"os": "darwin", "arch": "arm64"},
+		},
+		"tags":   "solo",
+		"labels": map[string]interface{}{"team": "tools", "tier": 1},
+		"parent": map[string]interface{}{"OS": "plan9"},
+		"extra":  []interface{}{1, "two"},
+		"port":   8080.0,
+	}
+	var cfg decodeConfig
+	cfg.internal = "kept"
+	if err := Decode(m, &cfg, DecodeOptions{}); err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+	checkEqual(t, "embedded", decodeCommon{Name: "dvln", Verbose: true}, cfg.decodeCommon)
+	checkEqual(t, "jobs", 8, cfg.Jobs)
+	checkEqual(t, "ratio", 1.0, cfg.Ratio)
+	checkEqual(t, "timeout", 3*24*time.Hour, cfg.Timeout)
+	checkEqual(t, "interval", units.Duration(90*time.Minute), cfg.Interval)
+	checkEqual(t, "cache", units.Size(10*units.GiB), cfg.Cache)
+	checkEqual(t, "targets", []decodeTarget{{"linux", "amd64"}, {"darwin", "arm64"}}, cfg.Targets)
+	checkEqual(t, "tags", []string{"solo"}, cfg.Tags)
+	checkEqual(t, "labels", map[string]string{"team": "tools", "tier": "1"}, cfg.Labels)
+	checkEqual(t, "parent", &decodeTarget{"plan9", "amd64"}, cfg.Parent)
+	checkEqual(t, "extra", []interface{}{1, "two"}, cfg.Extra)
+	checkEqual(t, "port", uint16(8080), cfg.Port)
+	checkEqual(t, "internal", "kept", cfg.internal)
+
+	// defaults only fill in missing keys
+	var def decodeConfig
+	if err := Decode(map[string]interface{}{}, &def, DecodeOptions{}); err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+	checkEqual(t, "default jobs", 4, def.Jobs)
+	checkEqual(t, "default timeout", 90*time.Second, def.Timeout)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	m := map[string]interface{}{
+		"jobs":     "many",
+		"timeout":  "soon",
+		"targets":  []interface{}{map[string]interface{}{"os": []interface{}{}}, "flat"},
+		"port":     70000,
+		"verbose":  "maybe",
+		"unknown":  1,
+		"interval": "1x",
+	}
+	var cfg decodeConfig
+	err := Decode(m, &cfg, DecodeOptions{ErrorUnused: true})
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected a DecodeError, got %v", err)
+	}
+	paths := []string{}
+	for _, fe := range decodeErr.Errors {
+		paths = append(paths, fe.Path)
+	}
+	checkEqual(t, "error paths", []string{"verbose", "jobs", "timeout", "interval", "targets[0].os", "targets[1]", "Port", "unknown"}, paths)
+	if !strings.HasPrefix(err.Error(), "8 errors decoding:\n* 'verbose': ") {
+		t.Fatalf("unexpected error message: %s", err)
+	}
+
+	if err := Decode(m, cfg, DecodeOptions{}); err == nil {
+		t.Fatalf("expected an error decoding into a non-pointer")
+	}
+}
+
+func TestDecodeOptions(t *testing.T) {
+	type strictCfg struct {
+		Jobs  int    `yaml:"jobs"`
+		Name  string `yaml:"name"`
+		Debug bool   `yaml:"debug"`
+	}
+	var cfg strictCfg
+	err := Decode(map[string]interface{}{"jobs": 4.0, "Name": "x", "debug": "true"}, &cfg, DecodeOptions{TagName: "yaml", Strict: true, CaseSensitive: true})
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || len(decodeErr.Errors) != 1 || decodeErr.Errors[0].Path != "debug" {
+		t.Fatalf("expected only a debug error in strict mode, got %v", err)
+	}
+	checkEqual(t, "strict jobs", 4, cfg.Jobs)
+	checkEqual(t, "case sensitive", "", cfg.Name)
+}
+
+type decodeInner struct {
+	A int `config:"a"`
+}
+
+type decodeOuter struct {
+	*decodeInner
+	B int `config:"b"`
+}
+
+func TestDecodeEmbeddedPointer(t *testing.T) {
+	// no data for the promoted field, the nil pointer is just skipped
+	var out decodeOuter
+	if err := Decode(map[string]interface{}{"b": 2}, &out, DecodeOptions{}); err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+	checkEqual(t, "b", 2, out.B)
+
+	// it can't be allocated, so data for it is an error rather than a panic
+	err := Decode(map[string]interface{}{"a": 1, "b": 2}, &out, DecodeOptions{})
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || len(decodeErr.Errors) != 1 || decodeErr.Errors[0].Path != "a" {
+		t.Fatalf("expected an error for a, got %v", err)
+	}
+	checkEqual(t, "b with error", 2, out.B)
+
+	// already set pointers are filled in
+	out = decodeOuter{decodeInner: &decodeInner{}}
+	if err := Decode(map[string]interface{}{"a": 1}, &out, DecodeOptions{}); err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+	checkEqual(t, "a", 1, out.A)
+}
+
+func TestDecodeSliceDefault(t *testing.T) {
+	type listConfig struct {
+		Tags  []string `config:"tags" default:"a,b"`
+		Ports []int    `config:"ports" default:"80,443"`
+		None  []string `config:"none" default:""`
+	}
+	var cfg listConfig
+	if err := Decode(map[string]interface{}{}, &cfg, DecodeOptions{Strict: true}); err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+	checkEqual(t, "tags", []string{"a", "b"}, cfg.Tags)
+	checkEqual(t, "ports", []int{80, 443}, cfg.Ports)
+	checkEqual(t, "none", []string{}, cfg.None)
+
+	// a plain string value is still a one item list
+	if err := Decode(map[string]interface{}{"tags": "a,b"}, &cfg, DecodeOptions{}); err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+	checkEqual(t, "tags value", []string{"a,b"}, cfg.Tags)
+}
+
+func TestDecodeIntegers(t *testing.T) {
+	type ints struct {
+		I64 int64  `config:"i64"`
+		U64 uint64 `config:"u64"`
+		I8  int8   `config:"i8"`
+	}
+	var out ints
+	m := map[string]interface{}{"i64": int64(9007199254740993), "u64": uint64(18446744073709551615), "i8": 3.0}
+	if err := Decode(m, &out, DecodeOptions{Strict: true}); err != nil {
+		t.Fatalf("Decode failed: %s", err)
+	}
+	checkEqual(t, "exact int64", int64(9007199254740993), out.I64)
+	checkEqual(t, "max uint64", uint64(18446744073709551615), out.U64)
+	checkEqual(t, "whole float", int8(3), out.I8)
+
+	if err := Decode(map[string]interface{}{"i64": "9007199254740993"}, &out, DecodeOptions{}); err != nil || out.I64 != 9007199254740993 {
+		t.Fatalf("expected a weak string to decode exactly, got %d (err: %v)", out.I64, err)
+	}
+	for _, bad := range []map[string]interface{}{
+		{"i8": 128}, {"i8": 1.5}, {"u64": -1}, {"i64": uint64(1 << 63)}, {"i64": 1e19},
+	} {
+		if err := Decode(bad, &out, DecodeOptions{Strict: true}); err == nil {
+			t.Fatalf("expected an error decoding %v", bad)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	cfg := decodeConfig{
+		decodeCommon: decodeCommon{Name: "dvln"},
+		Jobs:         2,
+		Timeout:      time.Minute,
+		Targets:      []decodeTarget{{"linux", "amd64"}},
+		Labels:       map[string]string{"team": "tools"},
+		Skipped:      "no",
+	}
+	m := Encode(&cfg)
+	checkEqual(t, "Encode", map[string]interface{}{
+		"name":     "dvln",
+		"verbose":  false,
+		"jobs":     2,
+		"ratio":    0.0,
+		"timeout":  time.Minute,
+		"interval": units.Duration(0),
+		"targets":  []interface{}{map[string]interface{}{"os": "linux", "arch": "amd64"}},
+		"tags":     nil,
+		"labels":   map[string]interface{}{"team": "tools"},
+		"parent":   nil,
+		"extra":    nil,
+		"Port":     uint16(0),
+	}, m)
+	checkEqual(t, "Encode non-struct", map[string]interface{}(nil), Encode(42))
+
+	// and back again
+	var back decodeConfig
+	if err := Decode(m, &back, DecodeOptions{}); err != nil {
+		t.Fatalf("Decode of Encode output failed: %s", err)
+	}
+	cfg.Skipped = ""
+	checkEqual(t, "round trip", cfg, back)
+}
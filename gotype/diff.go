@@ -0,0 +1,275 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeType is the kind of a Change found by Diff
+type ChangeType string
+
+// The change types, a modified value can also change type (ie: a map
+// replaced by a string)
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeRemoved  ChangeType = "removed"
+	ChangeModified ChangeType = "modified"
+)
+
+// Change is one difference between two map trees, Path uses the dotted
+// path syntax of Get() (ie: "build.targets[1].os", with `[""]` for an
+// empty key), Old is unset for an added value and New for a removed one
+type Change struct {
+	Type ChangeType  `json:"type"`
+	Path string      `json:"path"`
+	Old  interface{} `json:"old"`
+	New  interface{} `json:"new"`
+}
+
+// MarshalJSON leaves out "old" for added and "new" for removed changes,
+// it can't use omitempty as nil, false, 0 and "" are valid values
+func (c Change) MarshalJSON() ([]byte, error) {
+	switch c.Type {
+	case ChangeAdded:
+		return json.Marshal(struct {
+			Type ChangeType  `json:"type"`
+			Path string      `json:"path"`
+			New  interface{} `json:"new"`
+		}{c.Type, c.Path, c.New})
+	case ChangeRemoved:
+		return json.Marshal(struct {
+			Type ChangeType  `json:"type"`
+			Path string      `json:"path"`
+			Old  interface{} `json:"old"`
+		}{c.Type, c.Path, c.Old})
+	}
+	type plain Change
+	return json.Marshal(plain(c))
+}
+
+func (c Change) String() string {
+	switch c.Type {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %v", c.Path, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %v", c.Path, c.Old)
+	}
+	return fmt.Sprintf("~ %s: %v -> %v", c.Path, c.Old, c.New)
+}
+
+// Diff returns the changes that turn a into b, nested maps are compared
+// key by key (in sorted order) and lists item by item, with items past
+// the end of the shorter list added or removed (removals are listed from
+// the last item back so Patch() can apply them in order).  Numbers are
+// compared by value so an int 4 matches a float64 4 from JSON.
+func Diff(a, b map[string]interface{}) []Change {
+	return diffMaps([]Change{}, a, b, nil)
+}
+
+// diffMaps adds the changes between two maps at path
+func diffMaps(changes []Change, a, b map[string]interface{}, path []pathElem) []Change {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		keyPath := append(path[:len(path):len(path)], pathElem{key: key})
+		aVal, inA := a[key]
+		bVal, inB := b[key]
+		switch {
+		case !inA:
+			changes = append(changes, Change{Type: ChangeAdded, Path: pathString(keyPath), New: bVal})
+		case !inB:
+			changes = append(changes, Change{Type: ChangeRemoved, Path: pathString(keyPath), Old: aVal})
+		default:
+			changes = diffValues(changes, aVal, bVal, keyPath)
+		}
+	}
+	return changes
+}
+
+// diffValues adds the changes between two values at path
+func diffValues(changes []Change, a, b interface{}, path []pathElem) []Change {
+	aMap, aIsMap := asStringMap(a)
+	bMap, bIsMap := asStringMap(b)
+	if aIsMap && bIsMap {
+		return diffMaps(changes, aMap, bMap, path)
+	}
+	aList, aIsList := a.([]interface{})
+	bList, bIsList := b.([]interface{})
+	if aIsList && bIsList {
+		common := len(aList)
+		if len(bList) < common {
+			common = len(bList)
+		}
+		for i := 0; i < common; i++ {
+			changes = diffValues(changes, aList[i], bList[i], append(path[:len(path):len(path)], pathElem{index: i, isIndex: true}))
+		}
+		for i := common; i < len(bList); i++ {
+			itemPath := append(path[:len(path):len(path)], pathElem{index: i, isIndex: true})
+			changes = append(changes, Change{Type: ChangeAdded, Path: pathString(itemPath), New: bList[i]})
+		}
+		for i := len(aList) - 1; i >= common; i-- {
+			itemPath := append(path[:len(path):len(path)], pathElem{index: i, isIndex: true})
+			changes = append(changes, Change{Type: ChangeRemoved, Path: pathString(itemPath), Old: aList[i]})
+		}
+		return changes
+	}
+	if !valuesEqual(a, b) {
+		changes = append(changes, Change{Type: ChangeModified, Path: pathString(path), Old: a, New: b})
+	}
+	return changes
+}
+
+// valuesEqual compares two leaf values, numbers of different types are
+// compared by value (integers exactly, see numbersEqual)
+func valuesEqual(a, b interface{}) bool {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if av.IsValid() && bv.IsValid() && numberKind(av.Kind()) != 0 && numberKind(bv.Kind()) != 0 {
+		return numbersEqual(av, bv)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Patch applies changes (as returned by Diff) to m in order, values are
+// copied in so m doesn't share nested maps or lists with the changes.
+// It stops at the first change that can't be applied.
+func Patch(m map[string]interface{}, changes []Change) error {
+	for _, c := range changes {
+		switch c.Type {
+		case ChangeAdded, ChangeModified:
+			if err := SetPath(m, c.Path, DeepCopy(c.New)); err != nil {
+				return fmt.Errorf("cannot apply %s change: %s", c.Type, err)
+			}
+		case ChangeRemoved:
+			if !Delete(m, c.Path) {
+				return fmt.Errorf("cannot apply removed change: nothing at '%s'", c.Path)
+			}
+		default:
+			return fmt.Errorf("unknown change type '%s' for '%s'", c.Type, c.Path)
+		}
+	}
+	return nil
+}
+
+// JSONPatchOp is one operation of a JSON Patch (RFC 6902)
+type JSONPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON leaves the value out of "remove" operations, it can't use
+// omitempty as false, 0, "" and null are valid values for the others
+func (op JSONPatchOp) MarshalJSON() ([]byte, error) {
+	if op.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{op.Op, op.Path})
+	}
+	type plain JSONPatchOp
+	return json.Marshal(plain(op))
+}
+
+// JSONPatch turns changes into JSON Patch (RFC 6902) operations, marshal
+// the result with encoding/json to get the patch document
+func JSONPatch(changes []Change) ([]JSONPatchOp, error) {
+	ops := make([]JSONPatchOp, 0, len(changes))
+	for _, c := range changes {
+		ptr, err := jsonPointer(c.Path)
+		if err != nil {
+			return nil, err
+		}
+		switch c.Type {
+		case ChangeAdded:
+			ops = append(ops, JSONPatchOp{Op: "add", Path: ptr, Value: c.New})
+		case ChangeRemoved:
+			ops = append(ops, JSONPatchOp{Op: "remove", Path: ptr})
+		case ChangeModified:
+			ops = append(ops, JSONPatchOp{Op: "replace", Path: ptr, Value: c.New})
+		default:
+			return nil, fmt.Errorf("unknown change type '%s' for '%s'", c.Type, c.Path)
+		}
+	}
+	return ops, nil
+}
+
+// jsonPointer converts a dotted path into a JSON Pointer (RFC 6901),
+// ie: "a.b[2]" is "/a/b/2"
+func jsonPointer(path string) (string, error) {
+	elems, err := parsePath(path)
+	if err != nil {
+		return "", err
+	}
+	escape := strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	for _, e := range elems {
+		b.WriteByte('/')
+		if e.isIndex {
+			b.WriteString(strconv.Itoa(e.index))
+		} else {
+			b.WriteString(escape.Replace(e.key))
+		}
+	}
+	return b.String(), nil
+}
+
+// MergePatch returns the JSON Merge Patch (RFC 7386) that turns a into b:
+// changed values from b, nil for removed keys and nested patches for maps
+// in both, lists can't be patched item by item so changed ones are given
+// whole.  Marshal it with encoding/json to get the patch document.  As a
+// null means "remove" in a merge patch, a key set to nil in b can't be
+// told apart from one removed from b.
+func MergePatch(a, b map[string]interface{}) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for key := range a {
+		if _, ok := b[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	for key, bVal := range b {
+		aVal, ok := a[key]
+		if !ok {
+			patch[key] = DeepCopy(bVal)
+			continue
+		}
+		aMap, aIsMap := asStringMap(aVal)
+		bMap, bIsMap := asStringMap(bVal)
+		if aIsMap && bIsMap {
+			if sub := MergePatch(aMap, bMap); len(sub) > 0 {
+				patch[key] = sub
+			}
+			continue
+		}
+		if len(diffValues(nil, aVal, bVal, nil)) > 0 {
+			patch[key] = DeepCopy(bVal)
+		}
+	}
+	return patch
+}
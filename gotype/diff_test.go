@@ -0,0 +1,147 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func diffTrees() (map[string]interface{}, map[string]interface{}) {
+	defaults := map[string]interface{}{
+		"jobs":  4,
+		"log":   map[string]interface{}{"level": "info", "file": "/var/log/dvln"},
+		"paths": []interface{}{"/a", "/b", "/c"},
+		"old":   true,
+		"a/b":   "x",
+	}
+	var workspace map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"jobs": 4,
+		"log": {"level": "debug", "file": "/var/log/dvln", "color": false},
+		"paths": ["/a", "/z"],
+		"a/b": "x",
+		"new": {"k": "v"}
+	}`), &workspace)
+	return defaults, workspace
+}
+
+func TestDiff(t *testing.T) {
+	a, b := diffTrees()
+	changes := Diff(a, b)
+	checkEqual(t, "Diff", []Change{
+		{Type: ChangeAdded, Path: "log.color", New: false},
+		{Type: ChangeModified, Path: "log.level", Old: "info", New: "debug"},
+		{Type: ChangeAdded, Path: "new", New: map[string]interface{}{"k": "v"}},
+		{Type: ChangeRemoved, Path: "old", Old: true},
+		{Type: ChangeModified, Path: "paths[1]", Old: "/b", New: "/z"},
+		{Type: ChangeRemoved, Path: "paths[2]", Old: "/c"},
+	}, changes)
+	checkEqual(t, "String", "~ log.level: info -> debug", changes[1].String())
+	checkEqual(t, "no changes", []Change{}, Diff(b, b))
+
+	// keys with dots are escaped
+	changes = Diff(map[string]interface{}{}, map[string]interface{}{"www.dvln.org": 1})
+	checkEqual(t, "escaped", `www\.dvln\.org`, changes[0].Path)
+
+	// a type change is a modification of the whole value
+	changes = Diff(map[string]interface{}{"x": map[string]interface{}{"y": 1}}, map[string]interface{}{"x": "flat"})
+	checkEqual(t, "type change", []Change{{Type: ChangeModified, Path: "x", Old: map[string]interface{}{"y": 1}, New: "flat"}}, changes)
+
+	// big integers are compared exactly, not through float64
+	changes = Diff(map[string]interface{}{"id": int64(9007199254740992)}, map[string]interface{}{"id": int64(9007199254740993)})
+	checkEqual(t, "big ints", 1, len(changes))
+	checkEqual(t, "int vs float", []Change{}, Diff(map[string]interface{}{"n": 4}, map[string]interface{}{"n": 4.0}))
+
+	// empty keys get a quoted path that parses back
+	a, b = map[string]interface{}{"m": map[string]interface{}{"": 1}}, map[string]interface{}{"m": map[string]interface{}{"": 2}, "": "top"}
+	changes = Diff(a, b)
+	checkEqual(t, "empty key paths", []string{`[""]`, `m[""]`}, []string{changes[0].Path, changes[1].Path})
+	if err := Patch(a, changes); err != nil {
+		t.Fatalf("Patch with empty keys failed: %s", err)
+	}
+	checkEqual(t, "empty keys patched", b, a)
+	ops, _ := JSONPatch(changes)
+	checkEqual(t, "empty key pointer", "/m/", ops[1].Path)
+}
+
+func TestChangeJSON(t *testing.T) {
+	data, _ := json.Marshal([]Change{
+		{Type: ChangeAdded, Path: "a", New: nil},
+		{Type: ChangeRemoved, Path: "b", Old: false},
+		{Type: ChangeModified, Path: "c", Old: "", New: 0},
+	})
+	checkEqual(t, "Change JSON", `[{"type":"added","path":"a","new":null},`+
+		`{"type":"removed","path":"b","old":false},`+
+		`{"type":"modified","path":"c","old":"","new":0}]`, string(data))
+}
+
+func TestPatch(t *testing.T) {
+	a, b := diffTrees()
+	changes := Diff(a, b)
+	if err := Patch(a, changes); err != nil {
+		t.Fatalf("Patch failed: %s", err)
+	}
+	checkEqual(t, "patched", []Change{}, Diff(a, b))
+
+	// growing a list
+	a = map[string]interface{}{"l": []interface{}{1}}
+	b = map[string]interface{}{"l": []interface{}{1, 2, 3}}
+	if err := Patch(a, Diff(a, b)); err != nil {
+		t.Fatalf("Patch failed: %s", err)
+	}
+	checkEqual(t, "grown", b, a)
+
+	// the patched map doesn't share values with the changes
+	a = map[string]interface{}{}
+	b = map[string]interface{}{"m": map[string]interface{}{"k": 1}}
+	Patch(a, Diff(a, b))
+	a["m"].(map[string]interface{})["k"] = 2
+	checkEqual(t, "copied", 1, b["m"].(map[string]interface{})["k"])
+
+	if err := Patch(a, []Change{{Type: ChangeRemoved, Path: "nope"}}); err == nil {
+		t.Fatalf("expected an error removing a missing key")
+	}
+	if err := Patch(a, []Change{{Type: "renamed", Path: "m"}}); err == nil {
+		t.Fatalf("expected an error for an unknown change type")
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	a, b := diffTrees()
+	ops, err := JSONPatch(Diff(a, b))
+	if err != nil {
+		t.Fatalf("JSONPatch failed: %s", err)
+	}
+	data, _ := json.Marshal(ops)
+	checkEqual(t, "JSONPatch", `[{"op":"add","path":"/log/color","value":false},`+
+		`{"op":"replace","path":"/log/level","value":"debug"},`+
+		`{"op":"add","path":"/new","value":{"k":"v"}},`+
+		`{"op":"remove","path":"/old"},`+
+		`{"op":"replace","path":"/paths/1","value":"/z"},`+
+		`{"op":"remove","path":"/paths/2"}]`, string(data))
+
+	ops, _ = JSONPatch([]Change{{Type: ChangeAdded, Path: `a/b~c\.d`, New: nil}})
+	data, _ = json.Marshal(ops)
+	checkEqual(t, "JSONPatch escaping", `[{"op":"add","path":"/a~1b~0c.d","value":null}]`, string(data))
+}
+
+func TestMergePatch(t *testing.T) {
+	a, b := diffTrees()
+	data, _ := json.Marshal(MergePatch(a, b))
+	checkEqual(t, "MergePatch", `{"log":{"color":false,"level":"debug"},"new":{"k":"v"},"old":null,"paths":["/a","/z"]}`, string(data))
+	checkEqual(t, "MergePatch same", map[string]interface{}{}, MergePatch(a, a))
+}
@@ -0,0 +1,343 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dvln/cast"
+)
+
+// Flatten turns a nested map tree into a single level map with the keys
+// of each level joined by sep and list items by their index, ie:
+// {"server": {"ports": [80, 443]}} is {"server.ports.0": 80,
+// "server.ports.1": 443} with a sep of ".".  Empty maps and lists are
+// kept as values so Unflatten() can rebuild them.  A sep (or "\") inside
+// a key is escaped with a "\" the way paths do it, so {"a.b": 1} is
+// "a\.b" and doesn't collide with {"a": {"b": 2}}.
+func Flatten(m map[string]interface{}, sep string) map[string]interface{} {
+	flat := map[string]interface{}{}
+	flattenInto(flat, m, "", sep)
+	return flat
+}
+
+// flattenInto adds the leaves below val to flat with the given key prefix
+func flattenInto(flat map[string]interface{}, val interface{}, prefix, sep string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + sep + key
+	}
+	if m, ok := asStringMap(val); ok && (len(m) > 0 || prefix == "") {
+		for key, item := range m {
+			flattenInto(flat, item, join(escapeFlat(key, sep)), sep)
+		}
+		return
+	}
+	if list, ok := val.([]interface{}); ok && len(list) > 0 {
+		for i, item := range list {
+			flattenInto(flat, item, join(strconv.Itoa(i)), sep)
+		}
+		return
+	}
+	flat[prefix] = val
+}
+
+// escapeFlat puts a "\" in front of any sep or "\" in a key
+func escapeFlat(key, sep string) string {
+	key = strings.ReplaceAll(key, "\\", "\\\\")
+	if sep != "" {
+		key = strings.ReplaceAll(key, sep, "\\"+sep)
+	}
+	return key
+}
+
+// splitFlat splits a flattened key on sep, undoing escapeFlat()
+func splitFlat(key, sep string) []string {
+	parts := []string{}
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\' && i+1 < len(key):
+			if sep != "" && strings.HasPrefix(key[i+1:], sep) {
+				b.WriteString(sep)
+				i += len(sep)
+			} else {
+				i++
+				b.WriteByte(key[i])
+			}
+		case sep != "" && strings.HasPrefix(key[i:], sep):
+			parts = append(parts, b.String())
+			b.Reset()
+			i += len(sep) - 1
+		default:
+			b.WriteByte(key[i])
+		}
+	}
+	return append(parts, b.String())
+}
+
+// joinFlat is the reverse of splitFlat()
+func joinFlat(parts []string, sep string) string {
+	escaped := make([]string, len(parts))
+	for i, part := range parts {
+		escaped[i] = escapeFlat(part, sep)
+	}
+	return strings.Join(escaped, sep)
+}
+
+// Unflatten rebuilds a nested map tree from flattened keys, a level whose
+// keys are all the indexes 0 to n-1 becomes a list.  A "\" escapes a sep
+// that's part of a key (see Flatten()).  It fails if a key is
+// both a value and a parent of other keys (ie: "a" and "a.b").
+func Unflatten(flat map[string]interface{}, sep string) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	root := map[string]interface{}{}
+	for _, key := range keys {
+		parts := splitFlat(key, sep)
+		cur := root
+		for i, part := range parts[:len(parts)-1] {
+			next, exists := cur[part]
+			if !exists {
+				next = map[string]interface{}{}
+				cur[part] = next
+			}
+			nextMap, ok := next.(map[string]interface{})
+			if !ok || isLeafMap(flat, parts[:i+1], sep) {
+				return nil, fmt.Errorf("cannot unflatten '%s': '%s' already has a value", key, joinFlat(parts[:i+1], sep))
+			}
+			cur = nextMap
+		}
+		last := parts[len(parts)-1]
+		if _, exists := cur[last]; exists {
+			return nil, fmt.Errorf("cannot unflatten '%s': it already has nested keys", key)
+		}
+		cur[last] = flat[key]
+	}
+	return listify(root).(map[string]interface{}), nil
+}
+
+// isLeafMap checks if the given key prefix is itself a flattened key,
+// which would make it a value rather than a parent (even if the value is
+// an empty map)
+func isLeafMap(flat map[string]interface{}, parts []string, sep string) bool {
+	_, ok := flat[joinFlat(parts, sep)]
+	return ok
+}
+
+// listify turns maps whose keys are all the indexes 0 to n-1 into lists,
+// working from the bottom up
+func listify(val interface{}) interface{} {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return val
+	}
+	for key, item := range m {
+		m[key] = listify(item)
+	}
+	if len(m) == 0 {
+		return m
+	}
+	list := make([]interface{}, len(m))
+	for key, item := range m {
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(m) || strconv.Itoa(i) != key {
+			return m
+		}
+		list[i] = item
+	}
+	return list
+}
+
+// EnvName returns the environment variable name for a flattened key, ie:
+// EnvName("app", "server.ports.0", ".") is "APP_SERVER_PORTS_0", anything
+// that isn't a letter or digit becomes a "_"
+func EnvName(prefix, key, sep string) string {
+	name := strings.Join(splitFlat(key, sep), "_")
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// EnvKey is the reverse of EnvName() for keys that are lower case and
+// have no "_" of their own, ie: EnvKey("app", "APP_SERVER_PORTS_0", ".")
+// is "server.ports.0", false if the name doesn't have the prefix.  Use
+// ApplyEnv() to match variables against the keys of an existing config.
+func EnvKey(prefix, name, sep string) (string, bool) {
+	if prefix != "" {
+		p := EnvName("", prefix, sep) + "_"
+		if !strings.HasPrefix(name, p) {
+			return "", false
+		}
+		name = name[len(p):]
+	}
+	if name == "" {
+		return "", false
+	}
+	return strings.Join(strings.Split(strings.ToLower(name), "_"), sep), true
+}
+
+// ApplyEnv overrides values in the map tree from environment variables
+// (as os.Environ() gives them), each existing value is looked for under
+// its EnvName() so keys with mixed case or "_" work too.  Values are
+// converted to the exact type of the value they replace (bool, any int,
+// uint or float type, or else a string).  It returns the flattened keys
+// (with a "." sep) it changed.
+func ApplyEnv(m map[string]interface{}, prefix string, environ []string) ([]string, error) {
+	env := map[string]string{}
+	for _, kv := range environ {
+		if i := strings.IndexByte(kv, '='); i > 0 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+	flat := Flatten(m, ".")
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	applied := []string{}
+	for _, key := range keys {
+		str, ok := env[EnvName(prefix, key, ".")]
+		if !ok {
+			continue
+		}
+		val, err := convertLike(flat[key], str)
+		if err != nil {
+			return applied, fmt.Errorf("invalid value for %s: %s", EnvName(prefix, key, "."), err)
+		}
+		path, _, err := walkFlat(m, key)
+		if err == nil {
+			err = SetPath(m, pathString(path), val)
+		}
+		if err != nil {
+			return applied, err
+		}
+		applied = append(applied, key)
+	}
+	return applied, nil
+}
+
+// ApplySet applies "key=value" assignments (ie: from repeated -set
+// flags) to the map tree in order, keys are flattened keys with a "."
+// sep ("server.ports.0=8080", with "\." for a dot in a key) matching
+// existing map keys case insensitively, missing keys are created.
+// Values are converted to the type of the value they replace as
+// ApplyEnv() does, new values are strings.
+func ApplySet(m map[string]interface{}, assignments []string) error {
+	for _, a := range assignments {
+		i := strings.IndexByte(a, '=')
+		if i <= 0 {
+			return fmt.Errorf("invalid assignment '%s': expected key=value", a)
+		}
+		key := a[:i]
+		path, existing, err := walkFlat(m, key)
+		if err != nil {
+			return err
+		}
+		val, err := convertLike(existing, a[i+1:])
+		if err != nil {
+			return fmt.Errorf("invalid value for '%s': %s", key, err)
+		}
+		if err := SetPath(m, pathString(path), val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertLike converts a string to the type of an existing value, any
+// of the builtin bool, int, uint or float types (out of range values are
+// an error), anything else gets the string
+func convertLike(existing interface{}, str string) (interface{}, error) {
+	rv := reflect.ValueOf(existing)
+	if !rv.IsValid() || rv.Type().PkgPath() != "" {
+		return str, nil
+	}
+	out := reflect.New(rv.Type()).Elem()
+	switch numberKind(rv.Kind()) {
+	case 'i':
+		n, err := strconv.ParseInt(str, 0, rv.Type().Bits())
+		if err != nil {
+			return nil, err
+		}
+		out.SetInt(n)
+	case 'u':
+		n, err := strconv.ParseUint(str, 0, rv.Type().Bits())
+		if err != nil {
+			return nil, err
+		}
+		out.SetUint(n)
+	case 'f':
+		n, err := strconv.ParseFloat(str, rv.Type().Bits())
+		if err != nil {
+			return nil, err
+		}
+		out.SetFloat(n)
+	default:
+		if rv.Kind() == reflect.Bool {
+			return cast.ToBoolE(str)
+		}
+		return str, nil
+	}
+	return out.Interface(), nil
+}
+
+// walkFlat resolves a flattened key ("." sep) to a path, numeric parts
+// index into existing lists (or append one past the end) and other parts
+// match map keys case insensitively, it returns the current value there
+// (nil if there's none)
+func walkFlat(m map[string]interface{}, key string) ([]pathElem, interface{}, error) {
+	var cur interface{} = m
+	path := []pathElem{}
+	for _, part := range splitFlat(key, ".") {
+		if list, ok := cur.([]interface{}); ok {
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i > len(list) {
+				return nil, nil, fmt.Errorf("cannot set '%s': bad index '%s'", key, part)
+			}
+			path = append(path, pathElem{index: i, isIndex: true})
+			cur = nil
+			if i < len(list) {
+				cur = list[i]
+			}
+			continue
+		}
+		cm, _ := asStringMap(cur)
+		part, _ = PathOptions{CaseInsensitive: true}.findKey(cm, part)
+		cur = cm[part]
+		path = append(path, pathElem{key: part})
+	}
+	return path, cur, nil
+}
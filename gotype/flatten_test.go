@@ -0,0 +1,202 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func flatTree() map[string]interface{} {
+	return map[string]interface{}{
+		"server": map[string]interface{}{
+			"ports":   []interface{}{80, 443},
+			"Host":    "localhost",
+			"tls":     false,
+			"timeout": 1.5,
+		},
+		"empty":  map[string]interface{}{},
+		"none":   []interface{}{},
+		"max_db": 10,
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	flat := Flatten(flatTree(), ".")
+	checkEqual(t, "Flatten", map[string]interface{}{
+		"server.ports.0": 80,
+		"server.ports.1": 443,
+		"server.Host":    "localhost",
+		"server.tls":     false,
+		"server.timeout": 1.5,
+		"empty":          map[string]interface{}{},
+		"none":           []interface{}{},
+		"max_db":         10,
+	}, flat)
+	checkEqual(t, "Flatten sep", 80, Flatten(flatTree(), "/")["server/ports/0"])
+	checkEqual(t, "Flatten empty", map[string]interface{}{}, Flatten(map[string]interface{}{}, "."))
+
+	back, err := Unflatten(flat, ".")
+	if err != nil {
+		t.Fatalf("Unflatten failed: %s", err)
+	}
+	checkEqual(t, "Unflatten", flatTree(), back)
+
+	// sparse or non-numeric indexes stay maps
+	back, _ = Unflatten(map[string]interface{}{"a.0": 1, "a.2": 2, "b.01": 3}, ".")
+	checkEqual(t, "Unflatten sparse", map[string]interface{}{
+		"a": map[string]interface{}{"0": 1, "2": 2},
+		"b": map[string]interface{}{"01": 3},
+	}, back)
+
+	for _, flat := range []map[string]interface{}{
+		{"a": 1, "a.b": 2},
+		{"a": map[string]interface{}{}, "a.b": 2},
+	} {
+		if _, err := Unflatten(flat, "."); err == nil {
+			t.Fatalf("expected Unflatten(%v) to fail", flat)
+		}
+	}
+
+	// keys holding the sep are escaped so they don't collide with nested
+	// ones and come back out as they were
+	dotted := map[string]interface{}{
+		"a.b": 1,
+		"a":   map[string]interface{}{"b": 2},
+		`c\d`: 3,
+	}
+	flat = Flatten(dotted, ".")
+	checkEqual(t, "Flatten escaped", map[string]interface{}{`a\.b`: 1, "a.b": 2, `c\\d`: 3}, flat)
+	back, err = Unflatten(flat, ".")
+	if err != nil {
+		t.Fatalf("Unflatten failed: %s", err)
+	}
+	checkEqual(t, "Unflatten escaped", dotted, back)
+	back, _ = Unflatten(Flatten(map[string]interface{}{"a::b": 1, "c:::d": 2}, "::"), "::")
+	checkEqual(t, "Unflatten long sep", map[string]interface{}{"a::b": 1, "c:::d": 2}, back)
+}
+
+func TestEnvNames(t *testing.T) {
+	checkEqual(t, "EnvName", "APP_SERVER_PORTS_0", EnvName("app", "server.ports.0", "."))
+	checkEqual(t, "EnvName", "SERVER_HOST", EnvName("", "server.Host", "."))
+	checkEqual(t, "EnvName", "MY_APP_LOG_FILE", EnvName("my-app", "log/file", "/"))
+	key, ok := EnvKey("app", "APP_SERVER_PORTS_0", ".")
+	checkEqual(t, "EnvKey", "server.ports.0", key)
+	checkEqual(t, "EnvKey ok", true, ok)
+	_, ok = EnvKey("app", "OTHER_SERVER", ".")
+	checkEqual(t, "EnvKey prefix", false, ok)
+	_, ok = EnvKey("app", "APP_", ".")
+	checkEqual(t, "EnvKey empty", false, ok)
+}
+
+func TestApplyEnv(t *testing.T) {
+	m := flatTree()
+	applied, err := ApplyEnv(m, "app", []string{
+		"APP_SERVER_PORTS_1=8443",
+		"APP_SERVER_HOST=example.com",
+		"APP_SERVER_TLS=true",
+		"APP_MAX_DB=20",
+		"APP_UNKNOWN=x",
+		"PATH=/bin",
+	})
+	if err != nil {
+		t.Fatalf("ApplyEnv failed: %s", err)
+	}
+	checkEqual(t, "applied", []string{"max_db", "server.Host", "server.ports.1", "server.tls"}, applied)
+	checkEqual(t, "port", 8443, GetInt(m, "server.ports[1]"))
+	checkEqual(t, "host", "example.com", GetString(m, "server.Host"))
+	checkEqual(t, "tls", true, m["server"].(map[string]interface{})["tls"])
+	checkEqual(t, "max_db", 20, m["max_db"])
+	checkEqual(t, "unknown", false, Has(m, "unknown"))
+
+	if _, err := ApplyEnv(m, "app", []string{"APP_SERVER_TLS=maybe"}); err == nil {
+		t.Fatalf("expected a conversion error")
+	}
+}
+
+func TestConvertLike(t *testing.T) {
+	tests := []struct {
+		existing interface{}
+		str      string
+		expected interface{}
+	}{
+		{true, "false", false},
+		{1, "2", 2},
+		{int8(1), "-2", int8(-2)},
+		{int32(1), "2", int32(2)},
+		{int64(1), "9007199254740993", int64(9007199254740993)},
+		{uint(1), "2", uint(2)},
+		{uint16(1), "0x10", uint16(16)},
+		{uint64(1), "18446744073709551615", uint64(18446744073709551615)},
+		{float32(1), "2.5", float32(2.5)},
+		{1.5, "2.5", 2.5},
+		{"x", "y", "y"},
+		{nil, "y", "y"},
+		{time.Second, "5s", "5s"},
+	}
+	for _, test := range tests {
+		val, err := convertLike(test.existing, test.str)
+		if err != nil {
+			t.Fatalf("convertLike(%#v, %q) failed: %s", test.existing, test.str, err)
+		}
+		checkEqual(t, fmt.Sprintf("convertLike(%#v, %q)", test.existing, test.str), test.expected, val)
+	}
+	for _, test := range []struct {
+		existing interface{}
+		str      string
+	}{{int8(1), "300"}, {uint(1), "-1"}, {float32(1), "1e40"}, {1, "1.5"}} {
+		if _, err := convertLike(test.existing, test.str); err == nil {
+			t.Fatalf("expected convertLike(%#v, %q) to fail", test.existing, test.str)
+		}
+	}
+}
+
+func TestApplySet(t *testing.T) {
+	m := flatTree()
+	err := ApplySet(m, []string{
+		"server.ports.0=8080",
+		"server.host=example.com",
+		"server.ports.2=9090",
+		"log.level=debug",
+		"server.timeout=2.5",
+	})
+	if err != nil {
+		t.Fatalf("ApplySet failed: %s", err)
+	}
+	checkEqual(t, "ports", []interface{}{8080, 443, "9090"}, getValue(m, "server.ports"))
+	checkEqual(t, "host", "example.com", GetString(m, "server.Host"))
+	checkEqual(t, "new", "debug", GetString(m, "log.level"))
+	checkEqual(t, "timeout", 2.5, getValue(m, "server.timeout"))
+
+	m["a.b"] = "x"
+	if err := ApplySet(m, []string{`a\.b=y`}); err != nil {
+		t.Fatalf("ApplySet failed: %s", err)
+	}
+	checkEqual(t, "escaped dot", "y", m["a.b"])
+
+	for _, bad := range []string{"novalue", "=x", "server.ports.9=1", "server.tls=maybe", "max_db.x=1"} {
+		if err := ApplySet(m, []string{bad}); err == nil {
+			t.Fatalf("expected ApplySet(%q) to fail", bad)
+		}
+	}
+}
+
+// getValue is Get without the found flag
+func getValue(m map[string]interface{}, path string) interface{} {
+	val, _ := Get(m, path)
+	return val
+}
@@ -13,8 +13,10 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// package gotype has some basic functions to make map keys case insensitive
-// and to see if a string is in a slice (of like type)
+// package gotype has some basic functions to work with maps (case
+// insensitive keys, deep merging, dotted path access such as "a.b[2].c")
+// along with generic helpers to search, filter and combine slices and
+// generic set types (Set, OrderedSet and SyncSet)
 package gotype
 
 import (
@@ -24,7 +26,9 @@ import (
 	"github.com/dvln/cast"
 )
 
-// InsensitiviseMap turns the keys into lower case (case insensitive)
+// InsensitiviseMap turns the keys into lower case (case insensitive), note
+// that the original spelling is lost and keys differing only in case clobber
+// each other, see CIMap for a case insensitive map without those problems
 func InsensitiviseMap(m map[string]interface{}) {
 	for key, val := range m {
 		lower := strings.ToLower(key)
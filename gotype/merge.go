@@ -0,0 +1,237 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/dvln/cast"
+)
+
+// MergeStrategy says what DeepMerge does when a key is in both maps and
+// the values aren't both maps (those are always merged key by key)
+type MergeStrategy int
+
+// The merge strategies, slices are only treated specially by MergeAppend
+// and MergeUnion, other values are overridden by them
+const (
+	MergeOverride     MergeStrategy = iota // src value replaces dst value
+	MergeKeepExisting                      // dst value is kept
+	MergeAppend                            // src slice is appended to dst slice
+	MergeUnion                             // src slice items not already in dst slice are appended
+)
+
+// MergeOptions controls DeepMerge, the zero value overrides everything
+// and matches keys exactly
+type MergeOptions struct {
+	// Strategy is the default strategy
+	Strategy MergeStrategy
+	// Paths sets the strategy for dotted key paths (ie: "build.flags"),
+	// it applies to everything below the path too unless a longer path
+	// is also given
+	Paths map[string]MergeStrategy
+	// CaseInsensitive matches keys (and Paths) ignoring case, the
+	// spelling already in dst is kept
+	CaseInsensitive bool
+}
+
+// MergeConflictError is returned by DeepMerge when a map would be merged
+// with something that isn't a map, Path is the full dotted key path
+type MergeConflictError struct {
+	Path string
+	Dst  interface{}
+	Src  interface{}
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("cannot merge %s into %s at '%s'", kindName(e.Src), kindName(e.Dst), e.Path)
+}
+
+// kindName describes a value for error messages
+func kindName(val interface{}) string {
+	if val == nil {
+		return "null"
+	}
+	switch reflect.TypeOf(val).Kind() {
+	case reflect.Map:
+		return "map"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	}
+	return fmt.Sprintf("%T", val)
+}
+
+// DeepMerge merges the src map tree into dst (which is modified), nested
+// maps are merged key by key and other values according to the strategy
+// for their path, see MergeOptions.  Values taken from src are copied so
+// later merges into dst don't change src.  It stops at the first
+// *MergeConflictError (or *KeyCollisionError if CaseInsensitive is set
+// and a map has keys differing only in case), leaving dst partly merged.
+//
+// Layering config is a matter of merging each layer in turn, ie:
+//
+//	cfg := map[string]interface{}{}
+//	for _, layer := range []map[string]interface{}{global, user, workspace} {
+//	    DeepMerge(cfg, layer, MergeOptions{})
+//	}
+func DeepMerge(dst, src map[string]interface{}, opts MergeOptions) error {
+	return mergeMaps(dst, src, "", opts)
+}
+
+// mergeMaps does the work for DeepMerge at the given path
+func mergeMaps(dst, src map[string]interface{}, path string, opts MergeOptions) error {
+	var index map[string]string
+	if opts.CaseInsensitive {
+		var err error
+		if index, err = foldIndex(dst, path); err != nil {
+			return err
+		}
+		if _, err = foldIndex(src, path); err != nil {
+			return err
+		}
+	}
+	keys := make([]string, 0, len(src))
+	for key := range src {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		dstKey := key
+		if opts.CaseInsensitive {
+			if existing, ok := index[strings.ToLower(key)]; ok {
+				dstKey = existing
+			}
+		}
+		keyPath := joinPath(path, dstKey)
+		dstVal, exists := dst[dstKey]
+		if !exists {
+			dst[dstKey] = DeepCopy(src[key])
+			continue
+		}
+		merged, err := mergeValues(dstVal, src[key], keyPath, opts)
+		if err != nil {
+			return err
+		}
+		dst[dstKey] = merged
+	}
+	return nil
+}
+
+// mergeValues merges two values found at the same path
+func mergeValues(dstVal, srcVal interface{}, path string, opts MergeOptions) (interface{}, error) {
+	dstMap, dstIsMap := asStringMap(dstVal)
+	srcMap, srcIsMap := asStringMap(srcVal)
+	switch {
+	case dstIsMap && srcIsMap:
+		if err := mergeMaps(dstMap, srcMap, path, opts); err != nil {
+			return nil, err
+		}
+		return dstMap, nil
+	case (dstIsMap || srcIsMap) && dstVal != nil && srcVal != nil:
+		return nil, &MergeConflictError{Path: path, Dst: dstVal, Src: srcVal}
+	}
+
+	strategy := opts.strategy(path)
+	if strategy == MergeKeepExisting {
+		return dstVal, nil
+	}
+	dstList, dstIsList := dstVal.([]interface{})
+	srcList, srcIsList := srcVal.([]interface{})
+	if dstIsList && srcIsList {
+		switch strategy {
+		case MergeAppend:
+			out := append([]interface{}{}, dstList...)
+			return append(out, DeepCopy(srcList)...), nil
+		case MergeUnion:
+			out := append([]interface{}{}, dstList...)
+			for _, item := range srcList {
+				if !containsDeep(out, item) {
+					out = append(out, DeepCopy(item))
+				}
+			}
+			return out, nil
+		}
+	}
+	return DeepCopy(srcVal), nil
+}
+
+// strategy returns the strategy for a path, from the longest matching
+// entry in Paths or the default
+func (opts MergeOptions) strategy(path string) MergeStrategy {
+	if opts.CaseInsensitive {
+		path = strings.ToLower(path)
+	}
+	best, bestLen := opts.Strategy, -1
+	for p, strategy := range opts.Paths {
+		if opts.CaseInsensitive {
+			p = strings.ToLower(p)
+		}
+		if (path == p || strings.HasPrefix(path, p+".")) && len(p) > bestLen {
+			best, bestLen = strategy, len(p)
+		}
+	}
+	return best
+}
+
+// foldIndex maps the lower case keys of m to their spelling, failing if
+// two keys only differ in case
+func foldIndex(m map[string]interface{}, path string) (map[string]string, error) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	index := make(map[string]string, len(m))
+	for _, key := range keys {
+		lower := strings.ToLower(key)
+		if existing, ok := index[lower]; ok {
+			return nil, &KeyCollisionError{Path: path, Key: key, Existing: existing}
+		}
+		index[lower] = key
+	}
+	return index, nil
+}
+
+// asStringMap returns a map[string]interface{} for any map, other map
+// types (ie: map[interface{}]interface{} from yaml.v2) are converted
+func asStringMap(val interface{}) (map[string]interface{}, bool) {
+	if m, ok := val.(map[string]interface{}); ok {
+		return m, true
+	}
+	if val == nil || reflect.TypeOf(val).Kind() != reflect.Map {
+		return nil, false
+	}
+	rv := reflect.ValueOf(val)
+	m := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		m[cast.ToString(iter.Key().Interface())] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+// containsDeep checks if the list has an item deeply equal to val
+func containsDeep(list []interface{}, val interface{}) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, val) {
+			return true
+		}
+	}
+	return false
+}
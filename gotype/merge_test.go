@@ -0,0 +1,125 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDeepMerge(t *testing.T) {
+	global := map[string]interface{}{
+		"log":    map[string]interface{}{"level": "info", "file": "/var/log/dvln"},
+		"paths":  []interface{}{"/usr/share/dvln"},
+		"editor": "vi",
+	}
+	user := map[string]interface{}{
+		"log":    map[interface{}]interface{}{"level": "debug"},
+		"paths":  []interface{}{"~/dvln", "/usr/share/dvln"},
+		"editor": "emacs",
+		"new":    map[string]interface{}{"x": 1},
+	}
+	cfg := map[string]interface{}{}
+	if err := DeepMerge(cfg, global, MergeOptions{}); err != nil {
+		t.Fatalf("merge failed: %s", err)
+	}
+	if err := DeepMerge(cfg, user, MergeOptions{}); err != nil {
+		t.Fatalf("merge failed: %s", err)
+	}
+	checkEqual(t, "override", map[string]interface{}{
+		"log":    map[string]interface{}{"level": "debug", "file": "/var/log/dvln"},
+		"paths":  []interface{}{"~/dvln", "/usr/share/dvln"},
+		"editor": "emacs",
+		"new":    map[string]interface{}{"x": 1},
+	}, cfg)
+	// the layers themselves are left alone
+	checkEqual(t, "src unchanged", "info", global["log"].(map[string]interface{})["level"])
+	cfg["new"].(map[string]interface{})["x"] = 2
+	checkEqual(t, "src copied", 1, user["new"].(map[string]interface{})["x"])
+}
+
+func TestDeepMergeStrategies(t *testing.T) {
+	dst := map[string]interface{}{
+		"paths":  []interface{}{"a", "b"},
+		"tags":   []interface{}{"a", "b"},
+		"keep":   map[string]interface{}{"name": "orig", "list": []interface{}{1}},
+		"editor": "vi",
+	}
+	src := map[string]interface{}{
+		"paths":  []interface{}{"b", "c"},
+		"tags":   []interface{}{"b", "c"},
+		"keep":   map[string]interface{}{"name": "new", "extra": true, "list": []interface{}{2}},
+		"editor": "emacs",
+	}
+	err := DeepMerge(dst, src, MergeOptions{
+		Strategy: MergeKeepExisting,
+		Paths: map[string]MergeStrategy{
+			"paths":     MergeAppend,
+			"tags":      MergeUnion,
+			"keep.list": MergeUnion,
+		},
+	})
+	if err != nil {
+		t.Fatalf("merge failed: %s", err)
+	}
+	checkEqual(t, "strategies", map[string]interface{}{
+		"paths":  []interface{}{"a", "b", "b", "c"},
+		"tags":   []interface{}{"a", "b", "c"},
+		"keep":   map[string]interface{}{"name": "orig", "extra": true, "list": []interface{}{1, 2}},
+		"editor": "vi",
+	}, dst)
+}
+
+func TestDeepMergeCaseInsensitive(t *testing.T) {
+	dst := map[string]interface{}{"Log": map[string]interface{}{"Level": "info"}}
+	src := map[string]interface{}{"LOG": map[string]interface{}{"level": "debug"}, "Paths": []interface{}{"x"}}
+	opts := MergeOptions{CaseInsensitive: true, Paths: map[string]MergeStrategy{"paths": MergeAppend}}
+	if err := DeepMerge(dst, src, opts); err != nil {
+		t.Fatalf("merge failed: %s", err)
+	}
+	if err := DeepMerge(dst, map[string]interface{}{"PATHS": []interface{}{"y"}}, opts); err != nil {
+		t.Fatalf("merge failed: %s", err)
+	}
+	checkEqual(t, "case insensitive", map[string]interface{}{
+		"Log":   map[string]interface{}{"Level": "debug"},
+		"Paths": []interface{}{"x", "y"},
+	}, dst)
+
+	err := DeepMerge(dst, map[string]interface{}{"a": 1, "A": 2}, opts)
+	var collision *KeyCollisionError
+	if !errors.As(err, &collision) {
+		t.Fatalf("expected a key collision, got %v", err)
+	}
+}
+
+func TestDeepMergeConflict(t *testing.T) {
+	dst := map[string]interface{}{"build": map[string]interface{}{"flags": map[string]interface{}{"race": true}}}
+	src := map[string]interface{}{"build": map[string]interface{}{"flags": "-race"}}
+	err := DeepMerge(dst, src, MergeOptions{})
+	var conflict *MergeConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a merge conflict, got %v", err)
+	}
+	checkEqual(t, "conflict path", "build.flags", conflict.Path)
+	checkEqual(t, "conflict message", "cannot merge string into map at 'build.flags'", err.Error())
+
+	// a null on either side isn't a conflict
+	dst = map[string]interface{}{"a": nil, "b": map[string]interface{}{}}
+	if err := DeepMerge(dst, map[string]interface{}{"a": map[string]interface{}{}, "b": nil}, MergeOptions{}); err != nil {
+		t.Fatalf("merge with nulls failed: %s", err)
+	}
+	checkEqual(t, "nulls", map[string]interface{}{"a": map[string]interface{}{}, "b": nil}, dst)
+}
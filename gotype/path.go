@@ -0,0 +1,408 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dvln/cast"
+)
+
+// pathElem is one step of a parsed key path, a map key or a list index
+type pathElem struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func (e pathElem) String() string {
+	if e.isIndex {
+		return "[" + strconv.Itoa(e.index) + "]"
+	}
+	if e.key == "" {
+		return `[""]`
+	}
+	return strings.NewReplacer(`\`, `\\`, ".", `\.`, "[", `\[`).Replace(e.key)
+}
+
+// parsePath splits a path like "a.b[2].c" into its steps, a backslash
+// escapes a ".", "[" or "\" that's part of a key (ie: "hosts.www\.dvln\.org")
+// and a quoted key in brackets is taken as is (ie: `a[""]` for an empty key)
+func parsePath(path string) ([]pathElem, error) {
+	elems := []pathElem{}
+	var key strings.Builder
+	hasKey, afterIndex := false, false
+	pushKey := func() error {
+		if !hasKey {
+			return fmt.Errorf("invalid path '%s': empty key", path)
+		}
+		elems = append(elems, pathElem{key: key.String()})
+		key.Reset()
+		hasKey = false
+		return nil
+	}
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if afterIndex && c != '.' && c != '[' {
+			return nil, fmt.Errorf("invalid path '%s': expected '.' or '[' after ']'", path)
+		}
+		switch c {
+		case '\\':
+			if i+1 == len(path) {
+				return nil, fmt.Errorf("invalid path '%s': trailing '\\'", path)
+			}
+			i++
+			key.WriteByte(path[i])
+			hasKey = true
+		case '.':
+			if afterIndex {
+				afterIndex = false
+				continue
+			}
+			if err := pushKey(); err != nil {
+				return nil, err
+			}
+		case '[':
+			if hasKey {
+				pushKey()
+			} else if !afterIndex && i > 0 {
+				return nil, fmt.Errorf("invalid path '%s': empty key", path)
+			}
+			if i+1 < len(path) && path[i+1] == '"' {
+				quoted, err := strconv.QuotedPrefix(path[i+1:])
+				if err != nil || i+1+len(quoted) >= len(path) || path[i+1+len(quoted)] != ']' {
+					return nil, fmt.Errorf("invalid path '%s': bad quoted key", path)
+				}
+				unquoted, _ := strconv.Unquote(quoted)
+				elems = append(elems, pathElem{key: unquoted})
+				i += 1 + len(quoted)
+				afterIndex = true
+				continue
+			}
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path '%s': missing ']'", path)
+			}
+			index, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid path '%s': bad index '%s'", path, path[i+1:i+end])
+			}
+			elems = append(elems, pathElem{index: index, isIndex: true})
+			i += end
+			afterIndex = true
+		default:
+			key.WriteByte(c)
+			hasKey = true
+		}
+	}
+	if !afterIndex {
+		if err := pushKey(); err != nil {
+			return nil, err
+		}
+	}
+	return elems, nil
+}
+
+// pathString joins path steps back together (for error messages)
+func pathString(elems []pathElem) string {
+	var b strings.Builder
+	for i, e := range elems {
+		if i > 0 && !e.isIndex && e.key != "" {
+			b.WriteByte('.')
+		}
+		b.WriteString(e.String())
+	}
+	return b.String()
+}
+
+// PathOptions controls how Get, SetPath, Delete and friends walk a map tree,
+// the zero value (which the package level functions use) matches keys
+// exactly
+type PathOptions struct {
+	// CaseInsensitive matches map keys ignoring case, Set() keeps the
+	// spelling of an existing key
+	CaseInsensitive bool
+}
+
+// findKey returns the key in m matching key, honoring CaseInsensitive
+// (an exact match wins, otherwise the first in sorted order)
+func (o PathOptions) findKey(m map[string]interface{}, key string) (string, bool) {
+	if _, ok := m[key]; ok || !o.CaseInsensitive {
+		return key, ok
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return key, false
+	}
+	sort.Strings(keys)
+	return keys[0], true
+}
+
+// Get returns the value at the path in the map tree, ie:
+// Get(m, "build.targets[0].os"), see parsePath for the syntax
+func (o PathOptions) Get(m map[string]interface{}, path string) (interface{}, bool) {
+	elems, err := parsePath(path)
+	if err != nil {
+		return nil, false
+	}
+	var cur interface{} = m
+	for _, e := range elems {
+		if e.isIndex {
+			list, ok := cur.([]interface{})
+			if !ok || e.index >= len(list) {
+				return nil, false
+			}
+			cur = list[e.index]
+			continue
+		}
+		cm, ok := asStringMap(cur)
+		if !ok {
+			return nil, false
+		}
+		key, ok := o.findKey(cm, e.key)
+		if !ok {
+			return nil, false
+		}
+		cur = cm[key]
+	}
+	return cur, true
+}
+
+// Has checks if there's a value (possibly nil) at the path
+func (o PathOptions) Has(m map[string]interface{}, path string) bool {
+	_, ok := o.Get(m, path)
+	return ok
+}
+
+// Set stores a value at the path, creating any missing maps along the
+// way, a list index may be one past the end to append.  Maps of other
+// types (ie: map[interface{}]interface{}) along the path are replaced by
+// map[string]interface{} copies.
+func (o PathOptions) Set(m map[string]interface{}, path string, val interface{}) error {
+	elems, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	if elems[0].isIndex {
+		return fmt.Errorf("cannot set '%s': the top level is a map", path)
+	}
+	_, err = o.setIn(m, elems, 0, val)
+	return err
+}
+
+// setIn sets the value below cur (the value at elems[:pos]) and returns
+// cur, or its replacement if it had to change (new map, grown list)
+func (o PathOptions) setIn(cur interface{}, elems []pathElem, pos int, val interface{}) (interface{}, error) {
+	if pos == len(elems) {
+		return val, nil
+	}
+	e := elems[pos]
+	if e.isIndex {
+		list, ok := cur.([]interface{})
+		if !ok && cur != nil {
+			return nil, fmt.Errorf("cannot set '%s': '%s' is %s, not a list", pathString(elems), pathString(elems[:pos]), kindName(cur))
+		}
+		if e.index > len(list) {
+			return nil, fmt.Errorf("cannot set '%s': index %d is past the end of '%s'", pathString(elems), e.index, pathString(elems[:pos]))
+		}
+		var child interface{}
+		if e.index < len(list) {
+			child = list[e.index]
+		}
+		child, err := o.setIn(child, elems, pos+1, val)
+		if err != nil {
+			return nil, err
+		}
+		if e.index == len(list) {
+			return append(list, child), nil
+		}
+		list[e.index] = child
+		return list, nil
+	}
+
+	cm, ok := asStringMap(cur)
+	if !ok {
+		if cur != nil {
+			return nil, fmt.Errorf("cannot set '%s': '%s' is %s, not a map", pathString(elems), pathString(elems[:pos]), kindName(cur))
+		}
+		cm = map[string]interface{}{}
+	}
+	key, _ := o.findKey(cm, e.key)
+	child, err := o.setIn(cm[key], elems, pos+1, val)
+	if err != nil {
+		return nil, err
+	}
+	cm[key] = child
+	return cm, nil
+}
+
+// Delete removes the value at the path (list items after it move down),
+// returning false if there was nothing there
+func (o PathOptions) Delete(m map[string]interface{}, path string) bool {
+	elems, err := parsePath(path)
+	if err != nil {
+		return false
+	}
+	last := elems[len(elems)-1]
+	parentPath := pathString(elems[:len(elems)-1])
+	var parent interface{} = m
+	if len(elems) > 1 {
+		var ok bool
+		if parent, ok = o.Get(m, parentPath); !ok {
+			return false
+		}
+	}
+
+	if last.isIndex {
+		list, ok := parent.([]interface{})
+		if !ok || last.index >= len(list) {
+			return false
+		}
+		list = append(list[:last.index:last.index], list[last.index+1:]...)
+		return o.Set(m, parentPath, list) == nil
+	}
+	pm, ok := asStringMap(parent)
+	if !ok {
+		return false
+	}
+	key, ok := o.findKey(pm, last.key)
+	if !ok {
+		return false
+	}
+	delete(pm, key)
+	if _, plain := parent.(map[string]interface{}); !plain {
+		// other map types were copied, put the copy in their place
+		return o.Set(m, parentPath, pm) == nil
+	}
+	return true
+}
+
+// GetString returns the value at the path as a string, "" if missing
+func (o PathOptions) GetString(m map[string]interface{}, path string) string {
+	val, _ := o.Get(m, path)
+	return cast.ToString(val)
+}
+
+// GetInt returns the value at the path as an int, 0 if missing
+func (o PathOptions) GetInt(m map[string]interface{}, path string) int {
+	val, _ := o.Get(m, path)
+	return cast.ToInt(val)
+}
+
+// GetBool returns the value at the path as a bool, false if missing
+func (o PathOptions) GetBool(m map[string]interface{}, path string) bool {
+	val, _ := o.Get(m, path)
+	return cast.ToBool(val)
+}
+
+// GetFloat64 returns the value at the path as a float64, 0 if missing
+func (o PathOptions) GetFloat64(m map[string]interface{}, path string) float64 {
+	val, _ := o.Get(m, path)
+	return cast.ToFloat64(val)
+}
+
+// GetDuration returns the value at the path as a time.Duration (strings
+// like "1m30s" or numbers of nanoseconds), 0 if missing
+func (o PathOptions) GetDuration(m map[string]interface{}, path string) time.Duration {
+	val, _ := o.Get(m, path)
+	return cast.ToDuration(val)
+}
+
+// GetStringSlice returns the value at the path as a []string
+func (o PathOptions) GetStringSlice(m map[string]interface{}, path string) []string {
+	val, _ := o.Get(m, path)
+	return cast.ToStringSlice(val)
+}
+
+// GetStringMap returns the value at the path as a map[string]interface{}
+func (o PathOptions) GetStringMap(m map[string]interface{}, path string) map[string]interface{} {
+	val, _ := o.Get(m, path)
+	return cast.ToStringMap(val)
+}
+
+// Get returns the value at the path in the map tree, ie:
+// Get(m, "build.targets[0].os"), keys are matched exactly (see
+// PathOptions for case insensitive matching) and a "\" escapes a "."
+// or "[" that's part of a key.  A malformed path (ie: "a[x]" or "a..b")
+// can't match anything so it gives (nil, false) the same as a missing
+// value, SetPath() is the one to use to find out what's wrong with a path.
+func Get(m map[string]interface{}, path string) (interface{}, bool) {
+	return PathOptions{}.Get(m, path)
+}
+
+// Has checks if there's a value (possibly nil) at the path, false for a
+// malformed path (see Get())
+func Has(m map[string]interface{}, path string) bool {
+	return PathOptions{}.Has(m, path)
+}
+
+// SetPath stores a value at the path, creating any missing maps along the
+// way, and returns an error for a malformed path.  It isn't just Set()
+// like its siblings because Set is already taken by the generic set type.
+func SetPath(m map[string]interface{}, path string, val interface{}) error {
+	return PathOptions{}.Set(m, path, val)
+}
+
+// Delete removes the value at the path, false if there was nothing
+// there (or the path is malformed)
+func Delete(m map[string]interface{}, path string) bool {
+	return PathOptions{}.Delete(m, path)
+}
+
+// GetString returns the value at the path as a string, "" if missing
+func GetString(m map[string]interface{}, path string) string {
+	return PathOptions{}.GetString(m, path)
+}
+
+// GetInt returns the value at the path as an int, 0 if missing
+func GetInt(m map[string]interface{}, path string) int {
+	return PathOptions{}.GetInt(m, path)
+}
+
+// GetBool returns the value at the path as a bool, false if missing
+func GetBool(m map[string]interface{}, path string) bool {
+	return PathOptions{}.GetBool(m, path)
+}
+
+// GetFloat64 returns the value at the path as a float64, 0 if missing
+func GetFloat64(m map[string]interface{}, path string) float64 {
+	return PathOptions{}.GetFloat64(m, path)
+}
+
+// GetDuration returns the value at the path as a time.Duration, 0 if
+// missing
+func GetDuration(m map[string]interface{}, path string) time.Duration {
+	return PathOptions{}.GetDuration(m, path)
+}
+
+// GetStringSlice returns the value at the path as a []string
+func GetStringSlice(m map[string]interface{}, path string) []string {
+	return PathOptions{}.GetStringSlice(m, path)
+}
+
+// GetStringMap returns the value at the path as a map[string]interface{}
+func GetStringMap(m map[string]interface{}, path string) map[string]interface{} {
+	return PathOptions{}.GetStringMap(m, path)
+}
@@ -0,0 +1,135 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"testing"
+	"time"
+)
+
+func testTree() map[string]interface{} {
+	return map[string]interface{}{
+		"build": map[string]interface{}{
+			"Timeout": "1m30s",
+			"jobs":    "4",
+			"targets": []interface{}{
+				map[string]interface{}{"os": "linux", "arch": "amd64"},
+				map[interface{}]interface{}{"os": "darwin"},
+			},
+			"tags": []interface{}{"a", "b", "c"},
+		},
+		"hosts": map[string]interface{}{
+			"www.dvln.org": map[string]interface{}{"port": 443},
+		},
+		"debug": true,
+		"none":  nil,
+	}
+}
+
+func TestGet(t *testing.T) {
+	m := testTree()
+	val, ok := Get(m, "build.targets[0].os")
+	checkEqual(t, "Get", "linux", val)
+	checkEqual(t, "Get ok", true, ok)
+	val, _ = Get(m, "build.targets[1].os")
+	checkEqual(t, "Get yaml.v2 map", "darwin", val)
+	val, _ = Get(m, `hosts.www\.dvln\.org.port`)
+	checkEqual(t, "Get escaped", 443, val)
+	checkEqual(t, "Has nil", true, Has(m, "none"))
+	for _, path := range []string{"build.timeout", "build.targets[2]", "build.tags.x", "debug.x", "build[0]", "", "a..b", "a[", "a[x]", "build.targets[0]x"} {
+		if Has(m, path) {
+			t.Fatalf("expected nothing at %q", path)
+		}
+	}
+
+	ci := PathOptions{CaseInsensitive: true}
+	val, ok = ci.Get(m, "BUILD.timeout")
+	checkEqual(t, "case insensitive Get", "1m30s", val)
+	checkEqual(t, "case insensitive ok", true, ok)
+}
+
+func TestPathQuotedKeys(t *testing.T) {
+	m := map[string]interface{}{"": map[string]interface{}{"a.b": 1}}
+	val, ok := Get(m, `[""]["a.b"]`)
+	checkEqual(t, "quoted keys", 1, val)
+	checkEqual(t, "quoted keys ok", true, ok)
+	for _, bad := range []string{`a["x]`, `a["x"`, `a["x"]b`} {
+		if _, err := parsePath(bad); err == nil {
+			t.Fatalf("expected an error parsing %s", bad)
+		}
+	}
+}
+
+func TestTypedGetters(t *testing.T) {
+	m := testTree()
+	checkEqual(t, "GetString", "linux", GetString(m, "build.targets[0].os"))
+	checkEqual(t, "GetString missing", "", GetString(m, "nope"))
+	checkEqual(t, "GetInt", 4, GetInt(m, "build.jobs"))
+	checkEqual(t, "GetBool", true, GetBool(m, "debug"))
+	checkEqual(t, "GetFloat64", float64(443), GetFloat64(m, `hosts.www\.dvln\.org.port`))
+	checkEqual(t, "GetDuration", 90*time.Second, GetDuration(m, "build.Timeout"))
+	checkEqual(t, "GetStringSlice", []string{"a", "b", "c"}, GetStringSlice(m, "build.tags"))
+	checkEqual(t, "GetStringMap", "darwin", GetStringMap(m, "build.targets[1]")["os"])
+	checkEqual(t, "case insensitive GetDuration", 90*time.Second, PathOptions{CaseInsensitive: true}.GetDuration(m, "build.timeout"))
+}
+
+func TestSetPath(t *testing.T) {
+	m := testTree()
+	if err := SetPath(m, "build.targets[1].arch", "arm64"); err != nil {
+		t.Fatalf("SetPath failed: %s", err)
+	}
+	checkEqual(t, "SetPath in yaml.v2 map", "arm64", GetString(m, "build.targets[1].arch"))
+	checkEqual(t, "SetPath kept the rest", "darwin", GetString(m, "build.targets[1].os"))
+
+	if err := SetPath(m, "build.tags[3]", "d"); err != nil {
+		t.Fatalf("SetPath append failed: %s", err)
+	}
+	checkEqual(t, "SetPath append", []string{"a", "b", "c", "d"}, GetStringSlice(m, "build.tags"))
+
+	if err := SetPath(m, "new.deep.list[0].name", "x"); err != nil {
+		t.Fatalf("SetPath creating maps failed: %s", err)
+	}
+	checkEqual(t, "SetPath created", map[string]interface{}{
+		"deep": map[string]interface{}{"list": []interface{}{map[string]interface{}{"name": "x"}}},
+	}, m["new"])
+
+	for _, path := range []string{"debug.x", "build.tags[9]", "build.tags.x", "[0]", "a..b"} {
+		if err := SetPath(m, path, 1); err == nil {
+			t.Fatalf("expected SetPath(%q) to fail", path)
+		}
+	}
+
+	ci := PathOptions{CaseInsensitive: true}
+	if err := ci.Set(m, "BUILD.TIMEOUT", "2m"); err != nil {
+		t.Fatalf("case insensitive Set failed: %s", err)
+	}
+	checkEqual(t, "kept spelling", "2m", GetString(m, "build.Timeout"))
+}
+
+func TestDeletePath(t *testing.T) {
+	m := testTree()
+	checkEqual(t, "Delete", true, Delete(m, "build.tags[1]"))
+	checkEqual(t, "Delete list item", []string{"a", "c"}, GetStringSlice(m, "build.tags"))
+	checkEqual(t, "Delete", true, Delete(m, "build.targets[1].os"))
+	checkEqual(t, "Delete in yaml.v2 map", false, Has(m, "build.targets[1].os"))
+	checkEqual(t, "Delete key", true, Delete(m, `hosts.www\.dvln\.org`))
+	checkEqual(t, "Delete key gone", 0, len(GetStringMap(m, "hosts")))
+	checkEqual(t, "Delete missing", false, Delete(m, "build.nope"))
+	checkEqual(t, "Delete bad index", false, Delete(m, "build.tags[5]"))
+	checkEqual(t, "Delete top", true, Delete(m, "debug"))
+	checkEqual(t, "case insensitive Delete", true, PathOptions{CaseInsensitive: true}.Delete(m, "BUILD.timeout"))
+	checkEqual(t, "case insensitive Delete gone", false, Has(m, "build.Timeout"))
+}
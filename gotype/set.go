@@ -0,0 +1,363 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"sort"
+	"sync"
+)
+
+// Set is an unordered set with O(1) membership tests, use NewSet() or
+// make(Set[T]) to create one (a nil Set can be read but not added to).
+// It marshals to and from a JSON array.
+type Set[T comparable] map[T]struct{}
+
+// NewSet returns a set holding the given items
+func NewSet[T comparable](items ...T) Set[T] {
+	s := make(Set[T], len(items))
+	s.Add(items...)
+	return s
+}
+
+// Add puts the items in the set
+func (s Set[T]) Add(items ...T) {
+	for _, item := range items {
+		s[item] = struct{}{}
+	}
+}
+
+// Remove takes the items out of the set
+func (s Set[T]) Remove(items ...T) {
+	for _, item := range items {
+		delete(s, item)
+	}
+}
+
+// Has checks if the item is in the set
+func (s Set[T]) Has(item T) bool {
+	_, ok := s[item]
+	return ok
+}
+
+// Len returns the number of items in the set
+func (s Set[T]) Len() int {
+	return len(s)
+}
+
+// Items returns the items in no particular order, see Sorted() or
+// SortedFunc() for a stable order
+func (s Set[T]) Items() []T {
+	items := make([]T, 0, len(s))
+	for item := range s {
+		items = append(items, item)
+	}
+	return items
+}
+
+// SortedFunc returns the items sorted with the given less function
+func (s Set[T]) SortedFunc(less func(a, b T) bool) []T {
+	items := s.Items()
+	sort.Slice(items, func(i, j int) bool { return less(items[i], items[j]) })
+	return items
+}
+
+// Sorted returns the items of a set of an ordered type (strings, ints,
+// floats...) in ascending order
+func Sorted[T cmp.Ordered](s Set[T]) []T {
+	return s.SortedFunc(cmp.Less[T])
+}
+
+// Clone returns a copy of the set
+func (s Set[T]) Clone() Set[T] {
+	c := make(Set[T], len(s))
+	for item := range s {
+		c[item] = struct{}{}
+	}
+	return c
+}
+
+// Union returns a new set with the items in either set
+func (s Set[T]) Union(other Set[T]) Set[T] {
+	u := s.Clone()
+	for item := range other {
+		u[item] = struct{}{}
+	}
+	return u
+}
+
+// Intersection returns a new set with the items in both sets
+func (s Set[T]) Intersection(other Set[T]) Set[T] {
+	small, big := s, other
+	if len(small) > len(big) {
+		small, big = big, small
+	}
+	i := make(Set[T])
+	for item := range small {
+		if big.Has(item) {
+			i[item] = struct{}{}
+		}
+	}
+	return i
+}
+
+// Difference returns a new set with the items of s that aren't in other
+func (s Set[T]) Difference(other Set[T]) Set[T] {
+	d := make(Set[T])
+	for item := range s {
+		if !other.Has(item) {
+			d[item] = struct{}{}
+		}
+	}
+	return d
+}
+
+// IsSubset checks if every item of s is also in other
+func (s Set[T]) IsSubset(other Set[T]) bool {
+	if len(s) > len(other) {
+		return false
+	}
+	for item := range s {
+		if !other.Has(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSuperset checks if s has every item of other
+func (s Set[T]) IsSuperset(other Set[T]) bool {
+	return other.IsSubset(s)
+}
+
+// Equal checks if both sets have the same items
+func (s Set[T]) Equal(other Set[T]) bool {
+	return len(s) == len(other) && s.IsSubset(other)
+}
+
+// MarshalJSON writes the set as a JSON array, the items are sorted by
+// their JSON encoding so the output is stable
+func (s Set[T]) MarshalJSON() ([]byte, error) {
+	encoded := make([][]byte, 0, len(s))
+	for item := range s {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, data)
+	}
+	sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+	return append(append([]byte("["), bytes.Join(encoded, []byte(","))...), ']'), nil
+}
+
+// UnmarshalJSON reads the set from a JSON array, duplicates are dropped
+func (s *Set[T]) UnmarshalJSON(data []byte) error {
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	*s = NewSet(items...)
+	return nil
+}
+
+// OrderedSet is a set that remembers the order items were first added
+// in, membership tests are O(1) while Remove() is O(n).  The zero value
+// is an empty set ready to use.
+type OrderedSet[T comparable] struct {
+	index map[T]int
+	items []T
+}
+
+// NewOrderedSet returns an ordered set holding the given items (minus any
+// duplicates) in the given order
+func NewOrderedSet[T comparable](items ...T) *OrderedSet[T] {
+	s := &OrderedSet[T]{}
+	s.Add(items...)
+	return s
+}
+
+// Add appends the items that aren't in the set yet
+func (s *OrderedSet[T]) Add(items ...T) {
+	if s.index == nil {
+		s.index = make(map[T]int, len(items))
+	}
+	for _, item := range items {
+		if _, ok := s.index[item]; ok {
+			continue
+		}
+		s.index[item] = len(s.items)
+		s.items = append(s.items, item)
+	}
+}
+
+// Remove takes the items out of the set, the rest keep their order
+func (s *OrderedSet[T]) Remove(items ...T) {
+	removed := false
+	for _, item := range items {
+		if _, ok := s.index[item]; ok {
+			delete(s.index, item)
+			removed = true
+		}
+	}
+	if !removed {
+		return
+	}
+	kept := s.items[:0]
+	for _, item := range s.items {
+		if _, ok := s.index[item]; ok {
+			s.index[item] = len(kept)
+			kept = append(kept, item)
+		}
+	}
+	// clear the tail so removed items can be garbage collected
+	var zero T
+	for i := len(kept); i < len(s.items); i++ {
+		s.items[i] = zero
+	}
+	s.items = kept
+}
+
+// Has checks if the item is in the set
+func (s *OrderedSet[T]) Has(item T) bool {
+	_, ok := s.index[item]
+	return ok
+}
+
+// Len returns the number of items in the set
+func (s *OrderedSet[T]) Len() int {
+	return len(s.items)
+}
+
+// Items returns a copy of the items in insertion order
+func (s *OrderedSet[T]) Items() []T {
+	return append([]T(nil), s.items...)
+}
+
+// Set returns the items as an unordered Set
+func (s *OrderedSet[T]) Set() Set[T] {
+	return NewSet(s.items...)
+}
+
+// MarshalJSON writes the set as a JSON array in insertion order
+func (s *OrderedSet[T]) MarshalJSON() ([]byte, error) {
+	if s.items == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s.items)
+}
+
+// UnmarshalJSON reads the set from a JSON array, keeping the order and
+// dropping duplicates
+func (s *OrderedSet[T]) UnmarshalJSON(data []byte) error {
+	var items []T
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	*s = OrderedSet[T]{}
+	s.Add(items...)
+	return nil
+}
+
+// SyncSet is a Set that's safe for concurrent use, the zero value is an
+// empty set ready to use
+type SyncSet[T comparable] struct {
+	mu  sync.RWMutex
+	set Set[T]
+}
+
+// NewSyncSet returns a concurrency safe set holding the given items
+func NewSyncSet[T comparable](items ...T) *SyncSet[T] {
+	return &SyncSet[T]{set: NewSet(items...)}
+}
+
+// Add puts the items in the set
+func (s *SyncSet[T]) Add(items ...T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.set == nil {
+		s.set = make(Set[T])
+	}
+	s.set.Add(items...)
+}
+
+// TryAdd adds the item if it's not in the set yet, returning true if it
+// was added (so only one of several goroutines adding it gets true)
+func (s *SyncSet[T]) TryAdd(item T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.set.Has(item) {
+		return false
+	}
+	if s.set == nil {
+		s.set = make(Set[T])
+	}
+	s.set[item] = struct{}{}
+	return true
+}
+
+// Remove takes the items out of the set
+func (s *SyncSet[T]) Remove(items ...T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set.Remove(items...)
+}
+
+// Has checks if the item is in the set
+func (s *SyncSet[T]) Has(item T) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.set.Has(item)
+}
+
+// Len returns the number of items in the set
+func (s *SyncSet[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.set)
+}
+
+// Items returns the items in no particular order
+func (s *SyncSet[T]) Items() []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.set.Items()
+}
+
+// Snapshot returns a copy of the current items as a plain Set
+func (s *SyncSet[T]) Snapshot() Set[T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.set.Clone()
+}
+
+// MarshalJSON writes the set as a sorted JSON array, see Set.MarshalJSON()
+func (s *SyncSet[T]) MarshalJSON() ([]byte, error) {
+	return s.Snapshot().MarshalJSON()
+}
+
+// UnmarshalJSON replaces the set's items with those of a JSON array
+func (s *SyncSet[T]) UnmarshalJSON(data []byte) error {
+	var set Set[T]
+	if err := set.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.set = set
+	return nil
+}
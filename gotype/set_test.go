@@ -0,0 +1,142 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gotype
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	s := NewSet("b", "a", "c", "a")
+	checkEqual(t, "Len", 3, s.Len())
+	checkEqual(t, "Has", true, s.Has("a"))
+	checkEqual(t, "Has", false, s.Has("z"))
+	checkEqual(t, "Sorted", []string{"a", "b", "c"}, Sorted(s))
+	checkEqual(t, "SortedFunc", []string{"c", "b", "a"}, s.SortedFunc(func(a, b string) bool { return a > b }))
+
+	s.Remove("b")
+	checkEqual(t, "Remove", []string{"a", "c"}, Sorted(s))
+
+	other := NewSet("c", "d")
+	checkEqual(t, "Union", []string{"a", "c", "d"}, Sorted(s.Union(other)))
+	checkEqual(t, "Intersection", []string{"c"}, Sorted(s.Intersection(other)))
+	checkEqual(t, "Difference", []string{"a"}, Sorted(s.Difference(other)))
+	checkEqual(t, "unchanged", []string{"a", "c"}, Sorted(s))
+
+	checkEqual(t, "IsSubset", true, NewSet("a").IsSubset(s))
+	checkEqual(t, "IsSubset", false, other.IsSubset(s))
+	checkEqual(t, "IsSuperset", true, s.IsSuperset(NewSet[string]()))
+	checkEqual(t, "Equal", true, s.Equal(NewSet("c", "a")))
+	checkEqual(t, "Equal", false, s.Equal(other))
+
+	var empty Set[int]
+	checkEqual(t, "nil Has", false, empty.Has(1))
+	checkEqual(t, "nil Len", 0, empty.Len())
+}
+
+func TestSetJSON(t *testing.T) {
+	data, err := json.Marshal(NewSet(3, 1, 2))
+	if err != nil {
+		t.Fatalf("marshal failed: %s", err)
+	}
+	checkEqual(t, "MarshalJSON", "[1,2,3]", string(data))
+
+	data, _ = json.Marshal(map[string]Set[string]{"pkgs": NewSet[string]()})
+	checkEqual(t, "MarshalJSON", `{"pkgs":[]}`, string(data))
+
+	var cfg struct {
+		Patterns Set[string] `json:"patterns"`
+	}
+	if err := json.Unmarshal([]byte(`{"patterns": ["*.go", "*.md", "*.go"]}`), &cfg); err != nil {
+		t.Fatalf("unmarshal failed: %s", err)
+	}
+	checkEqual(t, "UnmarshalJSON", []string{"*.go", "*.md"}, Sorted(cfg.Patterns))
+	if err := json.Unmarshal([]byte(`{"patterns": "*.go"}`), &cfg); err == nil {
+		t.Fatalf("expected an error unmarshaling a string into a set")
+	}
+}
+
+func TestOrderedSet(t *testing.T) {
+	var s OrderedSet[string]
+	s.Add("c", "a", "b", "a")
+	checkEqual(t, "Items", []string{"c", "a", "b"}, s.Items())
+	checkEqual(t, "Has", true, s.Has("b"))
+	s.Remove("a", "zz")
+	checkEqual(t, "Remove", []string{"c", "b"}, s.Items())
+	checkEqual(t, "Has removed", false, s.Has("a"))
+	s.Add("a")
+	checkEqual(t, "re-Add", []string{"c", "b", "a"}, s.Items())
+	checkEqual(t, "Len", 3, s.Len())
+	checkEqual(t, "Set", true, s.Set().Equal(NewSet("a", "b", "c")))
+
+	data, err := json.Marshal(&s)
+	if err != nil {
+		t.Fatalf("marshal failed: %s", err)
+	}
+	checkEqual(t, "MarshalJSON", `["c","b","a"]`, string(data))
+	o := NewOrderedSet[int]()
+	if err := json.Unmarshal([]byte("[5, 3, 5, 1]"), o); err != nil {
+		t.Fatalf("unmarshal failed: %s", err)
+	}
+	checkEqual(t, "UnmarshalJSON", []int{5, 3, 1}, o.Items())
+	data, _ = json.Marshal(&OrderedSet[int]{})
+	checkEqual(t, "empty MarshalJSON", "[]", string(data))
+}
+
+func TestSyncSet(t *testing.T) {
+	var s SyncSet[string]
+	var wg sync.WaitGroup
+	added := make(chan string, 100)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				name := fmt.Sprintf("pkg%d", j)
+				if s.TryAdd(name) {
+					added <- name
+				}
+				s.Has(name)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(added)
+	// each name is only reported as added once
+	checkEqual(t, "TryAdd", 10, len(added))
+	checkEqual(t, "Len", 10, s.Len())
+
+	s.Remove("pkg0")
+	s.Add("extra")
+	snap := s.Snapshot()
+	s.Add("later")
+	checkEqual(t, "Snapshot", false, snap.Has("later"))
+	checkEqual(t, "Snapshot", true, snap.Has("extra") && !snap.Has("pkg0"))
+
+	small := NewSyncSet("b", "a")
+	data, err := json.Marshal(small)
+	if err != nil {
+		t.Fatalf("marshal failed: %s", err)
+	}
+	checkEqual(t, "MarshalJSON", `["a","b"]`, string(data))
+	if err := json.Unmarshal([]byte(`["x"]`), small); err != nil {
+		t.Fatalf("unmarshal failed: %s", err)
+	}
+	checkEqual(t, "UnmarshalJSON", []string{"x"}, small.Items())
+}
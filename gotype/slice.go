@@ -15,12 +15,138 @@
 
 package gotype
 
-// StringInSlice is checking exactly that, is the string in the slice
+// StringInSlice is checking exactly that, is the string in the slice,
+// it's kept for existing callers, see Contains()
 func StringInSlice(a string, list []string) bool {
-	for _, b := range list {
-		if b == a {
-			return true
+	return Contains(list, a)
+}
+
+// Contains checks if the value is in the slice
+func Contains[T comparable](list []T, val T) bool {
+	return Index(list, val) >= 0
+}
+
+// Index returns the index of the first occurrence of the value in the
+// slice, -1 if it's not there
+func Index[T comparable](list []T, val T) int {
+	for i, item := range list {
+		if item == val {
+			return i
+		}
+	}
+	return -1
+}
+
+// Filter returns a new slice with the items that keep() returns true for
+func Filter[T any](list []T, keep func(T) bool) []T {
+	var out []T
+	for _, item := range list {
+		if keep(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Map returns a new slice with fn() applied to each item
+func Map[T, U any](list []T, fn func(T) U) []U {
+	if list == nil {
+		return nil
+	}
+	out := make([]U, len(list))
+	for i, item := range list {
+		out[i] = fn(item)
+	}
+	return out
+}
+
+// Reduce folds the slice into a single value starting from init, ie:
+// Reduce(nums, 0, func(sum, n int) int { return sum + n })
+func Reduce[T, A any](list []T, init A, fn func(A, T) A) A {
+	acc := init
+	for _, item := range list {
+		acc = fn(acc, item)
+	}
+	return acc
+}
+
+// Uniq returns a new slice without duplicates, keeping the first
+// occurrence of each value in its original order
+func Uniq[T comparable](list []T) []T {
+	var out []T
+	seen := make(map[T]struct{}, len(list))
+	for _, item := range list {
+		if _, ok := seen[item]; ok {
+			continue
 		}
+		seen[item] = struct{}{}
+		out = append(out, item)
 	}
-	return false
+	return out
+}
+
+// Chunk splits the slice into slices of (at most) size items, the chunks
+// share the original slice's storage.  It panics if size is less than 1.
+func Chunk[T any](list []T, size int) [][]T {
+	if size < 1 {
+		panic("gotype: Chunk size must be at least 1")
+	}
+	var out [][]T
+	for len(list) > size {
+		out = append(out, list[:size:size])
+		list = list[size:]
+	}
+	if len(list) > 0 {
+		out = append(out, list)
+	}
+	return out
+}
+
+// Partition splits the slice into the items pred() returns true for and
+// those it returns false for, keeping their order
+func Partition[T any](list []T, pred func(T) bool) (yes, no []T) {
+	for _, item := range list {
+		if pred(item) {
+			yes = append(yes, item)
+		} else {
+			no = append(no, item)
+		}
+	}
+	return yes, no
+}
+
+// GroupBy groups the items by the key that key() returns for them, each
+// group keeps the original order
+func GroupBy[T any, K comparable](list []T, key func(T) K) map[K][]T {
+	out := make(map[K][]T)
+	for _, item := range list {
+		k := key(item)
+		out[k] = append(out[k], item)
+	}
+	return out
+}
+
+// Difference returns the unique items of a that aren't in b, in the
+// order they appear in a
+func Difference[T comparable](a, b []T) []T {
+	exclude := NewSet(b...)
+	return Filter(Uniq(a), func(item T) bool {
+		return !exclude.Has(item)
+	})
+}
+
+// Intersection returns the unique items of a that are also in b, in the
+// order they appear in a
+func Intersection[T comparable](a, b []T) []T {
+	include := NewSet(b...)
+	return Filter(Uniq(a), func(item T) bool {
+		return include.Has(item)
+	})
+}
+
+// Union returns the unique items of a followed by those of b that
+// weren't in a
+func Union[T comparable](a, b []T) []T {
+	out := make([]T, 0, len(a)+len(b))
+	return Uniq(append(append(out, a...), b...))
 }
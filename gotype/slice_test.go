@@ -15,7 +15,12 @@
 
 package gotype
 
-import "testing"
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
 
 // See if string inside slice check is working
 func TestStringInSlice(t *testing.T) {
@@ -30,3 +35,72 @@ func TestStringInSlice(t *testing.T) {
 		t.Fatal("Failed to find string in slice, should have been there")
 	}
 }
+
+func checkEqual(t *testing.T, what string, expected, actual interface{}) {
+	t.Helper()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("%s: expected %#v, got %#v", what, expected, actual)
+	}
+}
+
+func TestContainsIndex(t *testing.T) {
+	nums := []int{3, 1, 4, 1, 5}
+	checkEqual(t, "Contains", true, Contains(nums, 4))
+	checkEqual(t, "Contains", false, Contains(nums, 9))
+	checkEqual(t, "Index", 1, Index(nums, 1))
+	checkEqual(t, "Index", -1, Index(nums, 9))
+	checkEqual(t, "Index", -1, Index(nil, "x"))
+}
+
+func TestFilterMapReduce(t *testing.T) {
+	nums := []int{1, 2, 3, 4, 5, 6}
+	even := func(n int) bool { return n%2 == 0 }
+	checkEqual(t, "Filter", []int{2, 4, 6}, Filter(nums, even))
+	checkEqual(t, "Filter", []int(nil), Filter(nums, func(int) bool { return false }))
+	checkEqual(t, "Map", []string{"1", "2", "3", "4", "5", "6"}, Map(nums, strconv.Itoa))
+	checkEqual(t, "Map", []string(nil), Map(nil, strconv.Itoa))
+	checkEqual(t, "Reduce", 21, Reduce(nums, 0, func(sum, n int) int { return sum + n }))
+	checkEqual(t, "Reduce", "abc", Reduce([]string{"a", "b", "c"}, "", func(acc, s string) string { return acc + s }))
+}
+
+func TestUniqChunk(t *testing.T) {
+	checkEqual(t, "Uniq", []string{"b", "a", "c"}, Uniq([]string{"b", "a", "b", "c", "a"}))
+	checkEqual(t, "Chunk", [][]int{{1, 2}, {3, 4}, {5}}, Chunk([]int{1, 2, 3, 4, 5}, 2))
+	checkEqual(t, "Chunk", [][]int{{1, 2}}, Chunk([]int{1, 2}, 5))
+	checkEqual(t, "Chunk", [][]int(nil), Chunk([]int{}, 3))
+
+	// appending to a chunk mustn't clobber the next one
+	chunks := Chunk([]int{1, 2, 3, 4}, 2)
+	_ = append(chunks[0], 99)
+	checkEqual(t, "Chunk", []int{3, 4}, chunks[1])
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected Chunk to panic with a size of 0")
+		}
+	}()
+	Chunk([]int{1}, 0)
+}
+
+func TestPartitionGroupBy(t *testing.T) {
+	yes, no := Partition([]int{1, 2, 3, 4, 5}, func(n int) bool { return n > 2 })
+	checkEqual(t, "Partition yes", []int{3, 4, 5}, yes)
+	checkEqual(t, "Partition no", []int{1, 2}, no)
+
+	groups := GroupBy([]string{"apple", "bob", "avocado", "cat", "banana"}, func(s string) string { return s[:1] })
+	checkEqual(t, "GroupBy", map[string][]string{
+		"a": {"apple", "avocado"},
+		"b": {"bob", "banana"},
+		"c": {"cat"},
+	}, groups)
+	checkEqual(t, "GroupBy", 2, len(GroupBy([]string{"A", "a", "B"}, strings.ToLower)))
+}
+
+func TestSetOps(t *testing.T) {
+	a := []string{"x", "y", "z", "y"}
+	b := []string{"y", "w"}
+	checkEqual(t, "Difference", []string{"x", "z"}, Difference(a, b))
+	checkEqual(t, "Intersection", []string{"y"}, Intersection(a, b))
+	checkEqual(t, "Union", []string{"x", "y", "z", "w"}, Union(a, b))
+	checkEqual(t, "Union", []int(nil), Union([]int{}, nil))
+}
@@ -0,0 +1,219 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package homedir
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Source is where the home dir lookups get their information from, by
+// default that's the process env, os/user and /etc/passwd.  Any func left
+// nil is treated as "not available" so tests (or tools running in odd
+// environments) can swap in their own via SetSource().
+type Source struct {
+	Getenv  func(string) string
+	Getuid  func() int
+	Current func() (*user.User, error)
+	Lookup  func(string) (*user.User, error)
+	Passwd  func() (io.ReadCloser, error)
+}
+
+// DefaultSource returns a new Source set up to use the real environment,
+// handy if you only want to override one piece of it
+func DefaultSource() *Source {
+	return &Source{
+		Getenv:  os.Getenv,
+		Getuid:  os.Getuid,
+		Current: user.Current,
+		Lookup:  user.Lookup,
+		Passwd:  func() (io.ReadCloser, error) { return os.Open("/etc/passwd") },
+	}
+}
+
+var (
+	mu       sync.Mutex
+	source   = DefaultSource()
+	cacheDir string
+	cacheEnv string // the home env vars cacheDir was looked up with
+)
+
+// SetSource swaps in a different source of env/user/passwd information
+// (nil restores the default) and clears any cached home dir
+func SetSource(src *Source) {
+	mu.Lock()
+	defer mu.Unlock()
+	if src == nil {
+		src = DefaultSource()
+	}
+	source = src
+	cacheDir = ""
+}
+
+// Reset clears the cached home dir so the next Dir() call looks it up
+// again (ie: after the passwd data changes)
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	cacheDir = ""
+}
+
+// Dir returns the current users home dir, unlike UserHomeDir() this
+// doesn't just trust $HOME: if that isn't set (cron, systemd units, etc)
+// it falls back to os/user and then to parsing /etc/passwd directly (so it
+// works in non-cgo builds).  The result is cached until Reset() is called
+// or $HOME (%HOMEDRIVE%, %HOMEPATH% or %USERPROFILE% on windows) changes,
+// an error is returned if no home dir can be found.
+func Dir() (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	env := homeEnv(source)
+	if cacheDir != "" && cacheEnv == env {
+		return cacheDir, nil
+	}
+	dir, err := lookupDir(source)
+	if err != nil {
+		return "", err
+	}
+	cacheDir, cacheEnv = dir, env
+	return dir, nil
+}
+
+// homeEnv returns the env vars lookupDir() uses joined up, so Dir() can
+// tell if they changed since the home dir was cached
+func homeEnv(src *Source) string {
+	if src.Getenv == nil {
+		return ""
+	}
+	if goos == "windows" {
+		return src.Getenv("HOMEDRIVE") + "\x00" + src.Getenv("HOMEPATH") + "\x00" + src.Getenv("USERPROFILE")
+	}
+	return src.Getenv("HOME")
+}
+
+// ForUser returns the home dir of the named user, via os/user with a
+// fallback to parsing /etc/passwd, an error is returned if there's no
+// such user (or no home dir for them)
+func ForUser(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("unable to find home directory: no user name given")
+	}
+	mu.Lock()
+	src := source
+	mu.Unlock()
+	if src.Lookup != nil {
+		if u, err := src.Lookup(name); err == nil && u.HomeDir != "" {
+			return u.HomeDir, nil
+		}
+	}
+	if dir, err := passwdHome(src, func(entry []string) bool { return entry[0] == name }); err != nil {
+		return "", err
+	} else if dir != "" {
+		return dir, nil
+	}
+	return "", fmt.Errorf("unable to find home directory for user %q", name)
+}
+
+// getenv reads an env var via the current source
+func getenv(name string) string {
+	mu.Lock()
+	src := source
+	mu.Unlock()
+	if src.Getenv == nil {
+		return ""
+	}
+	return src.Getenv(name)
+}
+
+// lookupDir does the uncached Dir() work against the given source
+func lookupDir(src *Source) (string, error) {
+	env := func(name string) string {
+		if src.Getenv == nil {
+			return ""
+		}
+		return src.Getenv(name)
+	}
+	if goos == "windows" {
+		if home := env("HOMEDRIVE") + env("HOMEPATH"); home != "" {
+			return home, nil
+		}
+		if home := env("USERPROFILE"); home != "" {
+			return home, nil
+		}
+	} else if home := env("HOME"); home != "" {
+		return home, nil
+	}
+	if src.Current != nil {
+		if u, err := src.Current(); err == nil && u.HomeDir != "" {
+			return u.HomeDir, nil
+		}
+	}
+	if src.Getuid != nil {
+		if uid := src.Getuid(); uid >= 0 {
+			id := strconv.Itoa(uid)
+			dir, err := passwdHome(src, func(entry []string) bool { return entry[2] == id })
+			if err != nil {
+				return "", err
+			}
+			if dir != "" {
+				return dir, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unable to determine home directory: $HOME not set and no passwd entry for the current user")
+}
+
+// passwdHome scans the passwd data for the first entry the match func
+// likes and returns its home dir ("" if nothing matched or there's no
+// passwd data available)
+func passwdHome(src *Source, match func([]string) bool) (string, error) {
+	if src.Passwd == nil {
+		return "", nil
+	}
+	r, err := src.Passwd()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("unable to read passwd data: %s", err)
+	}
+	defer r.Close()
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// name:password:uid:gid:gecos:home:shell
+		entry := strings.Split(line, ":")
+		if len(entry) < 7 {
+			continue
+		}
+		if match(entry) {
+			return entry[5], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("unable to read passwd data: %s", err)
+	}
+	return "", nil
+}
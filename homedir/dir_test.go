@@ -0,0 +1,137 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package homedir
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/user"
+	"strings"
+	"testing"
+)
+
+const testPasswd = `# comment line
+root:x:0:0:root:/root:/bin/bash
+broken:line
+dvln:x:1000:1000:DVLN User,,,:/home/dvln:/bin/bash
+
+build:x:1001:1001::/srv/build:/usr/sbin/nologin
+`
+
+// fakeSource returns a Source with the given env, uid and passwd data and
+// no os/user support (like a non-cgo build with no nss)
+func fakeSource(env map[string]string, uid int, passwd string) *Source {
+	return &Source{
+		Getenv: func(name string) string { return env[name] },
+		Getuid: func() int { return uid },
+		Passwd: func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(passwd)), nil
+		},
+	}
+}
+
+// Home dir from $HOME, then caching, $HOME changes and Reset()
+func TestDirFromEnv(t *testing.T) {
+	env := map[string]string{"HOME": "/home/fromenv"}
+	SetSource(fakeSource(env, 1000, testPasswd))
+	defer SetSource(nil)
+
+	dir, err := Dir()
+	if err != nil || dir != "/home/fromenv" {
+		t.Fatalf("Expected home dir \"/home/fromenv\", found \"%s\" (err: %v)", dir, err)
+	}
+	env["HOME"] = "/home/changed"
+	if dir, _ = Dir(); dir != "/home/changed" {
+		t.Fatalf("Expected home dir \"/home/changed\" after $HOME changed, found \"%s\"", dir)
+	}
+
+	// fallback results stay cached until Reset()
+	delete(env, "HOME")
+	src := fakeSource(env, 1001, testPasswd)
+	SetSource(src)
+	if dir, _ = Dir(); dir != "/srv/build" {
+		t.Fatalf("Expected passwd home dir \"/srv/build\", found \"%s\"", dir)
+	}
+	src.Getuid = func() int { return 1000 }
+	if dir, _ = Dir(); dir != "/srv/build" {
+		t.Fatalf("Expected cached home dir \"/srv/build\", found \"%s\"", dir)
+	}
+	Reset()
+	if dir, _ = Dir(); dir != "/home/dvln" {
+		t.Fatalf("Expected home dir \"/home/dvln\" after Reset(), found \"%s\"", dir)
+	}
+}
+
+// Home dir fallbacks when $HOME isn't set
+func TestDirFallbacks(t *testing.T) {
+	src := fakeSource(map[string]string{}, 1001, testPasswd)
+	SetSource(src)
+	defer SetSource(nil)
+
+	dir, err := Dir()
+	if err != nil || dir != "/srv/build" {
+		t.Fatalf("Expected passwd home dir \"/srv/build\", found \"%s\" (err: %v)", dir, err)
+	}
+
+	src.Current = func() (*user.User, error) { return &user.User{HomeDir: "/home/osuser"}, nil }
+	Reset()
+	if dir, _ = Dir(); dir != "/home/osuser" {
+		t.Fatalf("Expected os/user home dir \"/home/osuser\", found \"%s\"", dir)
+	}
+
+	SetSource(fakeSource(map[string]string{}, 4242, testPasswd))
+	if dir, err = Dir(); err == nil {
+		t.Fatalf("Should have failed to find a home dir for an unknown uid, found \"%s\"", dir)
+	}
+
+	broken := fakeSource(map[string]string{}, 1000, "")
+	broken.Passwd = func() (io.ReadCloser, error) { return nil, fmt.Errorf("permission denied") }
+	SetSource(broken)
+	if _, err = Dir(); err == nil {
+		t.Fatal("Should have failed with an unreadable passwd source")
+	}
+}
+
+// Home dir lookups for a named user
+func TestForUser(t *testing.T) {
+	src := fakeSource(map[string]string{"HOME": "/home/me"}, 1000, testPasswd)
+	SetSource(src)
+	defer SetSource(nil)
+
+	dir, err := ForUser("dvln")
+	if err != nil || dir != "/home/dvln" {
+		t.Fatalf("Expected home dir \"/home/dvln\" for user dvln, found \"%s\" (err: %v)", dir, err)
+	}
+	if dir, err = ForUser("nobody-here"); err == nil {
+		t.Fatalf("Should have failed to find home dir for unknown user, found \"%s\"", dir)
+	}
+	if _, err = ForUser(""); err == nil {
+		t.Fatal("Should have failed to find home dir for an empty user name")
+	}
+	src.Lookup = func(name string) (*user.User, error) {
+		if name == "root" {
+			return &user.User{Username: name, HomeDir: "/var/root"}, nil
+		}
+		return nil, user.UnknownUserError(name)
+	}
+	if dir, _ = ForUser("root"); dir != "/var/root" {
+		t.Fatalf("Expected os/user home dir \"/var/root\" for root, found \"%s\"", dir)
+	}
+	if dir, _ = ForUser("dvln"); dir != "/home/dvln" {
+		t.Fatalf("Expected passwd fallback home dir \"/home/dvln\", found \"%s\"", dir)
+	}
+}
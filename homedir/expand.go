@@ -0,0 +1,78 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package homedir
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsSep checks for a path separator, "/" is always accepted so that
+// "~/x" (or "$HOME/x") works on windows as well
+func IsSep(c byte) bool {
+	return c == '/' || c == os.PathSeparator
+}
+
+// Expand does shell style tilde expansion on the given path: "~" and "~/x"
+// use the current users home dir (see Dir()) and "~user" and "~user/x" use
+// the named users home dir (see ForUser()).  Paths not starting with a "~"
+// are returned untouched, an error is returned if the home dir can't be
+// resolved (ie: an unknown user).
+func Expand(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+	end := 1
+	for end < len(path) && !IsSep(path[end]) {
+		end++
+	}
+	var home string
+	var err error
+	if end == 1 {
+		home, err = Dir()
+	} else {
+		home, err = ForUser(path[1:end])
+	}
+	if err != nil {
+		return "", err
+	}
+	return home + path[end:], nil
+}
+
+// Collapse is the reverse of Expand() for display purposes, if the path
+// is in the current users home dir that part is replaced with "~" (eg:
+// "/home/me/ws" becomes "~/ws").  Other paths, or any path if the home
+// dir can't be found, are returned unchanged.
+func Collapse(path string) string {
+	home, err := Dir()
+	if err != nil || path == "" {
+		return path
+	}
+	home = filepath.Clean(home)
+	if home == string(filepath.Separator) || home == "." {
+		// collapsing everything under "/" would just be confusing
+		return path
+	}
+	clean := filepath.Clean(path)
+	if clean == home {
+		return "~"
+	}
+	if strings.HasPrefix(clean, home) && IsSep(clean[len(home)]) {
+		return "~" + clean[len(home):]
+	}
+	return path
+}
@@ -0,0 +1,87 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package homedir
+
+import "testing"
+
+// Tilde expansion for the current and named users
+func TestExpand(t *testing.T) {
+	SetSource(fakeSource(map[string]string{"HOME": "/home/me"}, 1000, testPasswd))
+	defer SetSource(nil)
+
+	tests := map[string]string{
+		"~":              "/home/me",
+		"~/":             "/home/me/",
+		"~/ws/src":       "/home/me/ws/src",
+		"~dvln":          "/home/dvln",
+		"~dvln/ws":       "/home/dvln/ws",
+		"/abs/~/path":    "/abs/~/path",
+		"relative/path":  "relative/path",
+		"":               "",
+		"$HOME/not/mine": "$HOME/not/mine",
+	}
+	for in, expected := range tests {
+		result, err := Expand(in)
+		if err != nil {
+			t.Fatalf("Unexpected error expanding \"%s\": %s", in, err)
+		}
+		if result != expected {
+			t.Fatalf("Expected \"%s\" to expand to \"%s\", got \"%s\"", in, expected, result)
+		}
+	}
+	for _, in := range []string{"~foo", "~foo/bar"} {
+		if result, err := Expand(in); err == nil {
+			t.Fatalf("Expected error expanding \"%s\" for an unknown user, got \"%s\"", in, result)
+		}
+	}
+
+	SetSource(fakeSource(map[string]string{}, 4242, testPasswd))
+	if result, err := Expand("~/x"); err == nil {
+		t.Fatalf("Expected error expanding \"~/x\" with no home dir, got \"%s\"", result)
+	}
+}
+
+// Collapsing home dir paths back to ~ for display
+func TestCollapse(t *testing.T) {
+	SetSource(fakeSource(map[string]string{"HOME": "/home/me/"}, 1000, testPasswd))
+	defer SetSource(nil)
+
+	tests := map[string]string{
+		"/home/me":       "~",
+		"/home/me/":      "~",
+		"/home/me/ws":    "~/ws",
+		"/home/me/ws/a/": "~/ws/a",
+		"/home/me2/ws":   "/home/me2/ws",
+		"/home/mex":      "/home/mex",
+		"/tmp/x":         "/tmp/x",
+		"relative/x":     "relative/x",
+		"":               "",
+	}
+	for in, expected := range tests {
+		if result := Collapse(in); result != expected {
+			t.Fatalf("Expected \"%s\" to collapse to \"%s\", got \"%s\"", in, expected, result)
+		}
+	}
+
+	SetSource(fakeSource(map[string]string{"HOME": "/"}, 1000, testPasswd))
+	if result := Collapse("/etc/passwd"); result != "/etc/passwd" {
+		t.Fatalf("Paths should not be collapsed with a home dir of \"/\", got \"%s\"", result)
+	}
+	SetSource(fakeSource(map[string]string{}, 4242, testPasswd))
+	if result := Collapse("/home/me/ws"); result != "/home/me/ws" {
+		t.Fatalf("Paths should be unchanged if there's no home dir, got \"%s\"", result)
+	}
+}
@@ -13,8 +13,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// package homedir home directory related functions (ie: get users home dir)
-// that will work across linux/unix/windows
+// package homedir home directory related functions (ie: get users home dir,
+// XDG config/cache/data dirs) that will work across linux/unix/windows
 package homedir
 
 import (
@@ -23,13 +23,13 @@ func TestHomeDirCheck(t *testing.T) {
 		os.Setenv("USERPROFILE", "C:\\some\\dir")
 		dir = UserHomeDir()
 		if dir == "" || dir != "C:\\some\\dir" {
-			t.Fatal("Should have found a windows home dir set to \"C:\\some\\dir\", but found: \"%s\"\n", dir)
+			t.Fatalf("Should have found a windows home dir set to \"C:\\some\\dir\", but found: \"%s\"\n", dir)
 		}
 		os.Setenv("HOMEDRIVE", "C:")
 		os.Setenv("HOMEPATH", "\\some\\dir")
 		dir = UserHomeDir()
 		if dir == "" || dir != "C:\\some\\dir" {
-			t.Fatal("Should have found a windows home dir set to \"C:\\some\\dir\", but found: \"%s\"\n", dir)
+			t.Fatalf("Should have found a windows home dir set to \"C:\\some\\dir\", but found: \"%s\"\n", dir)
 		}
 	}
 }
@@ -0,0 +1,187 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package homedir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// goos is the platform the home/XDG dir lookups behave like, tests
+// change it to check the windows and macOS layouts
+var goos = runtime.GOOS
+
+// ConfigDir returns the per-user config dir for the given app following
+// the XDG Base Directory spec ($XDG_CONFIG_HOME, default ~/.config/<app>).
+// On windows this is %APPDATA%\<app> and on macOS it is
+// ~/Library/Application Support/<app> unless $XDG_CONFIG_HOME is set.
+// If app is "" the base dir itself is returned.  The dir is not created.
+func ConfigDir(app string) (string, error) {
+	return userDir(app, "XDG_CONFIG_HOME", ".config", "APPDATA", "Library/Application Support")
+}
+
+// CacheDir returns the per-user cache dir for the given app, which is
+// $XDG_CACHE_HOME/<app> (default ~/.cache/<app>), %LOCALAPPDATA%\<app>\cache
+// on windows or ~/Library/Caches/<app> on macOS.
+func CacheDir(app string) (string, error) {
+	if getenv("XDG_CACHE_HOME") == "" && goos == "windows" {
+		dir, err := userDir(app, "", "", "LOCALAPPDATA", "")
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "cache"), nil
+	}
+	return userDir(app, "XDG_CACHE_HOME", ".cache", "LOCALAPPDATA", "Library/Caches")
+}
+
+// DataDir returns the per-user data dir for the given app, which is
+// $XDG_DATA_HOME/<app> (default ~/.local/share/<app>), %LOCALAPPDATA%\<app>
+// on windows or ~/Library/Application Support/<app> on macOS.
+func DataDir(app string) (string, error) {
+	return userDir(app, "XDG_DATA_HOME", ".local/share", "LOCALAPPDATA", "Library/Application Support")
+}
+
+// StateDir returns the per-user state dir (logs, history, etc) for the
+// given app, which is $XDG_STATE_HOME/<app> (default ~/.local/state/<app>),
+// %LOCALAPPDATA%\<app> on windows or ~/Library/Application Support/<app>
+// on macOS.
+func StateDir(app string) (string, error) {
+	return userDir(app, "XDG_STATE_HOME", ".local/state", "LOCALAPPDATA", "Library/Application Support")
+}
+
+// RuntimeDir returns the per-user runtime dir (sockets, pid files, etc)
+// for the given app, which is $XDG_RUNTIME_DIR/<app>.  If that isn't set
+// (or on windows/macOS) we fall back to a per-user dir in the system temp
+// dir, as the spec suggests, which the caller should create as mode 0700.
+func RuntimeDir(app string) (string, error) {
+	if dir := xdgEnv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, app), nil
+	}
+	base := "runtime"
+	if uid := os.Getuid(); uid >= 0 {
+		base += "-" + strconv.Itoa(uid)
+	}
+	return filepath.Join(os.TempDir(), base, app), nil
+}
+
+// ConfigDirs returns the config search path for the given app in order
+// of precedence, the user config dir first followed by $XDG_CONFIG_DIRS
+// (default /etc/xdg), or %PROGRAMDATA% on windows and
+// /Library/Application Support on macOS.
+func ConfigDirs(app string) ([]string, error) {
+	return searchDirs(app, ConfigDir, "XDG_CONFIG_DIRS", []string{"/etc/xdg"})
+}
+
+// DataDirs returns the data search path for the given app in order of
+// precedence, the user data dir first followed by $XDG_DATA_DIRS (default
+// /usr/local/share:/usr/share), or the windows and macOS equivalents as
+// used by ConfigDirs().
+func DataDirs(app string) ([]string, error) {
+	return searchDirs(app, DataDir, "XDG_DATA_DIRS", []string{"/usr/local/share", "/usr/share"})
+}
+
+// FindConfigFile looks for the named config file (which may include sub
+// dirs) in the ConfigDirs() search path and returns the first one that
+// exists, or "" if there is no such file anywhere (any unexpected error
+// will come back in the error return parameter)
+func FindConfigFile(app, name string) (string, error) {
+	dirs, err := ConfigDirs(app)
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range dirs {
+		file := filepath.Join(dir, name)
+		fileinfo, err := os.Stat(file)
+		if err == nil && !fileinfo.IsDir() {
+			return file, nil
+		}
+		if err != nil && !os.IsNotExist(err) && !os.IsPermission(err) {
+			return "", fmt.Errorf("unable to check for config file %s: %s", file, err)
+		}
+	}
+	return "", nil
+}
+
+// xdgEnv returns the value of the given XDG env var, the spec says that
+// relative paths in these are invalid and should be ignored
+func xdgEnv(name string) string {
+	dir := getenv(name)
+	if dir == "" || !filepath.IsAbs(dir) {
+		return ""
+	}
+	return dir
+}
+
+// userDir is the common lookup for the per-user dirs, an XDG env var wins
+// on every platform, otherwise we use the platforms usual location
+func userDir(app, xdgVar, unixDir, winVar, macDir string) (string, error) {
+	if dir := xdgEnv(xdgVar); dir != "" {
+		return filepath.Join(dir, app), nil
+	}
+	if goos == "windows" {
+		if dir := getenv(winVar); dir != "" {
+			return filepath.Join(dir, app), nil
+		}
+	}
+	home, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	switch goos {
+	case "windows":
+		// no %APPDATA% and friends, use the usual XDG layout under home
+	case "darwin":
+		return filepath.Join(home, macDir, app), nil
+	}
+	return filepath.Join(home, unixDir, app), nil
+}
+
+// searchDirs builds the user dir followed by the system search list
+func searchDirs(app string, userFn func(string) (string, error), xdgVar string, defaults []string) ([]string, error) {
+	userDir, err := userFn(app)
+	if err != nil {
+		return nil, err
+	}
+	dirs := []string{userDir}
+	var system []string
+	if val := getenv(xdgVar); val != "" {
+		for _, dir := range strings.Split(val, string(os.PathListSeparator)) {
+			if filepath.IsAbs(dir) {
+				system = append(system, dir)
+			}
+		}
+	}
+	if len(system) == 0 {
+		switch goos {
+		case "windows":
+			if dir := getenv("PROGRAMDATA"); dir != "" {
+				system = []string{dir}
+			}
+		case "darwin":
+			system = []string{"/Library/Application Support"}
+		default:
+			system = defaults
+		}
+	}
+	for _, dir := range system {
+		dirs = append(dirs, filepath.Join(dir, app))
+	}
+	return dirs, nil
+}
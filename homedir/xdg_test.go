@@ -0,0 +1,216 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package homedir
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var xdgVars = []string{
+	"HOME", "APPDATA", "LOCALAPPDATA", "PROGRAMDATA",
+	"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_DATA_HOME", "XDG_STATE_HOME",
+	"XDG_RUNTIME_DIR", "XDG_CONFIG_DIRS", "XDG_DATA_DIRS",
+}
+
+// clearXDGEnv saves and clears all the env vars the XDG routines look at
+// and sets HOME, the returned func restores everything
+func clearXDGEnv(home string) func() {
+	saved := saveEnv(xdgVars...)
+	origGOOS := goos
+	for _, name := range xdgVars {
+		os.Unsetenv(name)
+	}
+	os.Setenv("HOME", home)
+	Reset()
+	return func() {
+		goos = origGOOS
+		restoreEnv(saved)
+		SetSource(nil)
+	}
+}
+
+// checkDir runs a dir func and compares the result
+func checkDir(t *testing.T, what string, fn func(string) (string, error), expected string) {
+	dir, err := fn("dvln")
+	if err != nil {
+		t.Fatalf("Unexpected error getting %s dir: %s", what, err)
+	}
+	if dir != expected {
+		t.Fatalf("Expected %s dir \"%s\", found \"%s\"", what, expected, dir)
+	}
+}
+
+// Default XDG layout and env var overrides on linux
+func TestXDGDirs(t *testing.T) {
+	defer clearXDGEnv("/home/me")()
+	goos = "linux"
+
+	checkDir(t, "config", ConfigDir, "/home/me/.config/dvln")
+	checkDir(t, "cache", CacheDir, "/home/me/.cache/dvln")
+	checkDir(t, "data", DataDir, "/home/me/.local/share/dvln")
+	checkDir(t, "state", StateDir, "/home/me/.local/state/dvln")
+
+	os.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	os.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+	os.Setenv("XDG_DATA_HOME", "relative/data") // invalid, must be ignored
+	os.Setenv("XDG_STATE_HOME", "/xdg/state")
+	os.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	checkDir(t, "config", ConfigDir, "/xdg/config/dvln")
+	checkDir(t, "cache", CacheDir, "/xdg/cache/dvln")
+	checkDir(t, "data", DataDir, "/home/me/.local/share/dvln")
+	checkDir(t, "state", StateDir, "/xdg/state/dvln")
+	checkDir(t, "runtime", RuntimeDir, "/run/user/1000/dvln")
+
+	os.Unsetenv("XDG_RUNTIME_DIR")
+	dir, err := RuntimeDir("dvln")
+	if err != nil || filepath.Dir(filepath.Dir(dir)) != filepath.Clean(os.TempDir()) {
+		t.Fatalf("Expected runtime dir fallback in temp dir, got \"%s\" (err: %v)", dir, err)
+	}
+
+	// no HOME and no passwd info to fall back on
+	os.Unsetenv("HOME")
+	os.Unsetenv("XDG_CACHE_HOME")
+	SetSource(&Source{Getenv: os.Getenv})
+	if _, err := CacheDir("dvln"); err == nil {
+		t.Fatal("Should have failed to get cache dir with no HOME or XDG_CACHE_HOME")
+	}
+}
+
+// Search lists for config and data dirs
+func TestXDGSearchDirs(t *testing.T) {
+	defer clearXDGEnv("/home/me")()
+	goos = "linux"
+
+	dirs, err := ConfigDirs("dvln")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dirs, []string{"/home/me/.config/dvln", "/etc/xdg/dvln"}) {
+		t.Fatalf("Unexpected default config dirs: %v", dirs)
+	}
+	dirs, err = DataDirs("dvln")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dirs, []string{"/home/me/.local/share/dvln", "/usr/local/share/dvln", "/usr/share/dvln"}) {
+		t.Fatalf("Unexpected default data dirs: %v", dirs)
+	}
+	os.Setenv("XDG_CONFIG_DIRS", "/opt/etc:relative:/etc/xdg")
+	dirs, err = ConfigDirs("dvln")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dirs, []string{"/home/me/.config/dvln", "/opt/etc/dvln", "/etc/xdg/dvln"}) {
+		t.Fatalf("Unexpected config dirs from XDG_CONFIG_DIRS: %v", dirs)
+	}
+}
+
+// Windows and macOS equivalents
+func TestXDGOtherPlatforms(t *testing.T) {
+	defer clearXDGEnv("/Users/me")()
+	goos = "darwin"
+	checkDir(t, "config", ConfigDir, "/Users/me/Library/Application Support/dvln")
+	checkDir(t, "cache", CacheDir, "/Users/me/Library/Caches/dvln")
+	dirs, err := ConfigDirs("dvln")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dirs, []string{"/Users/me/Library/Application Support/dvln", "/Library/Application Support/dvln"}) {
+		t.Fatalf("Unexpected macOS config dirs: %v", dirs)
+	}
+
+	goos = "windows"
+	os.Setenv("APPDATA", "/c/Users/me/AppData/Roaming")
+	os.Setenv("LOCALAPPDATA", "/c/Users/me/AppData/Local")
+	os.Setenv("PROGRAMDATA", "/c/ProgramData")
+	checkDir(t, "config", ConfigDir, "/c/Users/me/AppData/Roaming/dvln")
+	checkDir(t, "data", DataDir, "/c/Users/me/AppData/Local/dvln")
+	checkDir(t, "cache", CacheDir, "/c/Users/me/AppData/Local/dvln/cache")
+	dirs, err = ConfigDirs("dvln")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dirs, []string{"/c/Users/me/AppData/Roaming/dvln", "/c/ProgramData/dvln"}) {
+		t.Fatalf("Unexpected windows config dirs: %v", dirs)
+	}
+}
+
+// Config files are found in precedence order
+func TestFindConfigFile(t *testing.T) {
+	tempFolder, err := ioutil.TempDir("", "dvln-util-homedir-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempFolder)
+	defer clearXDGEnv(filepath.Join(tempFolder, "home"))()
+	goos = "linux"
+	sysDir := filepath.Join(tempFolder, "etc")
+	os.Setenv("XDG_CONFIG_DIRS", sysDir)
+
+	userFile := filepath.Join(tempFolder, "home", ".config", "dvln", "dvln.toml")
+	sysFile := filepath.Join(sysDir, "dvln", "dvln.toml")
+	for _, file := range []string{userFile, sysFile} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(sysFile, []byte("system"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	found, err := FindConfigFile("dvln", "dvln.toml")
+	if err != nil || found != sysFile {
+		t.Fatalf("Expected to find system config file %s, found \"%s\" (err: %v)", sysFile, found, err)
+	}
+	if err := ioutil.WriteFile(userFile, []byte("user"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	found, err = FindConfigFile("dvln", "dvln.toml")
+	if err != nil || found != userFile {
+		t.Fatalf("Expected to find user config file %s, found \"%s\" (err: %v)", userFile, found, err)
+	}
+	found, err = FindConfigFile("dvln", "missing.toml")
+	if err != nil || found != "" {
+		t.Fatalf("Expected no config file to be found, found \"%s\" (err: %v)", found, err)
+	}
+}
+
+// saveEnv records the current values of the given env vars
+func saveEnv(names ...string) map[string]*string {
+	saved := make(map[string]*string)
+	for _, name := range names {
+		if val, ok := os.LookupEnv(name); ok {
+			saved[name] = &val
+		} else {
+			saved[name] = nil
+		}
+	}
+	return saved
+}
+
+// restoreEnv puts back env vars recorded by saveEnv
+func restoreEnv(saved map[string]*string) {
+	for name, val := range saved {
+		if val == nil {
+			os.Unsetenv(name)
+		} else {
+			os.Setenv(name, *val)
+		}
+	}
+}
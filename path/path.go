@@ -29,17 +29,28 @@ import (
 // AbsPathify takes a path and attempts to clean it up and turn
 // it into an absolute path via filepath.Clean and filepath.Abs
 func AbsPathify(inPath string) string {
-	if strings.HasPrefix(inPath, "$HOME") {
-		inPath = homedir.UserHomeDir() + inPath[5:]
+	if inPath == "$HOME" || (strings.HasPrefix(inPath, "$HOME") && homedir.IsSep(inPath[5])) {
+		inPath = "~" + inPath[5:]
 	}
 
-	if strings.HasPrefix(inPath, "$") {
-		end := strings.Index(inPath, string(os.PathSeparator))
-		inPath = os.Getenv(inPath[1:end]) + inPath[end:]
+	if strings.HasPrefix(inPath, "~") {
+		// don't let an unknown home dir quietly turn ~/x into /x
+		expanded, err := homedir.Expand(inPath)
+		if err != nil {
+			out.Errorln("Couldn't discover absolute path for:", inPath)
+			out.Errorln("  Error:", err)
+			return ""
+		}
+		inPath = expanded
 	}
 
-	if strings.HasPrefix(inPath, "~") {
-		inPath = homedir.UserHomeDir() + inPath[1:]
+	if strings.HasPrefix(inPath, "$") {
+		// no separator means the whole thing is the var name, ie: "$FOO"
+		end := 1
+		for end < len(inPath) && !homedir.IsSep(inPath[end]) {
+			end++
+		}
+		inPath = os.Getenv(inPath[1:end]) + inPath[end:]
 	}
 
 	if filepath.IsAbs(inPath) {
@@ -20,6 +20,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/dvln/util/homedir"
 )
 
 // Poor test on pathify, need some symlinks/etc eventually
@@ -58,6 +60,67 @@ func TestAbsPathify(t *testing.T) {
 	if results == "~/tmp" {
 		t.Fatalf("AbsPathify() failed to translate ~ correctly: %s\n", results)
 	}
+	home, err := homedir.Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	results = AbsPathify("~")
+	if results != filepath.Clean(home) {
+		t.Fatalf("AbsPathify() failed to translate ~ to %s: %s\n", home, results)
+	}
+	results = AbsPathify("~no-such-dvln-user/tmp")
+	if results != "" {
+		t.Fatalf("AbsPathify() should have failed for an unknown user, returned: %s\n", results)
+	}
+}
+
+// An explicit $HOME follows the env even after the home dir was cached
+func TestAbsPathifyHomeChange(t *testing.T) {
+	orig := os.Getenv("HOME")
+	defer os.Setenv("HOME", orig)
+	os.Setenv("HOME", "/dvln/home1")
+	if results := AbsPathify("$HOME/x"); results != filepath.Clean("/dvln/home1/x") {
+		t.Fatalf("AbsPathify() expected /dvln/home1/x, returned: %s\n", results)
+	}
+	os.Setenv("HOME", "/dvln/home2")
+	if results := AbsPathify("$HOME/x"); results != filepath.Clean("/dvln/home2/x") {
+		t.Fatalf("AbsPathify() expected /dvln/home2/x after changing HOME, returned: %s\n", results)
+	}
+	if results := AbsPathify("~/x"); results != filepath.Clean("/dvln/home2/x") {
+		t.Fatalf("AbsPathify() expected ~ to follow HOME too, returned: %s\n", results)
+	}
+}
+
+// Env vars without a separator after them shouldn't blow up
+func TestAbsPathifyVarOnly(t *testing.T) {
+	defer os.Unsetenv("DVLN_PATH_TEST")
+	os.Setenv("DVLN_PATH_TEST", "/dvln/var")
+	if results := AbsPathify("$DVLN_PATH_TEST"); results != filepath.Clean("/dvln/var") {
+		t.Fatalf("AbsPathify() expected /dvln/var, returned: %s\n", results)
+	}
+	if results := AbsPathify("$DVLN_PATH_TEST/x"); results != filepath.Clean("/dvln/var/x") {
+		t.Fatalf("AbsPathify() expected /dvln/var/x, returned: %s\n", results)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "$HOMEX" is its own (unset) var, not $HOME with an X on the end
+	if results := AbsPathify("$HOMEX_DVLN_UNSET"); results != cwd {
+		t.Fatalf("AbsPathify() expected an unset var to give the cwd (%s), returned: %s\n", cwd, results)
+	}
+}
+
+// An unknown home dir should fail rather than turning ~/tmp into /tmp
+func TestAbsPathifyNoHome(t *testing.T) {
+	homedir.SetSource(&homedir.Source{Getenv: func(string) string { return "" }})
+	defer homedir.SetSource(nil)
+	if results := AbsPathify("~/tmp"); results != "" {
+		t.Fatalf("AbsPathify() should have failed with no home dir, returned: %s\n", results)
+	}
+	if results := AbsPathify("$HOME/tmp"); results != "" {
+		t.Fatalf("AbsPathify() should have failed with no home dir, returned: %s\n", results)
+	}
 }
 
 func TestCreateIfNotExistsDir(t *testing.T) {
@@ -0,0 +1,299 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package progress wraps an io.Reader or io.Writer to count the bytes going
+// through it and report the progress (bytes done, total, rate and ETA) to
+// a callback at throttled intervals, the Renderer type is a ready made
+// callback that draws a progress bar on a terminal or logs periodic lines
+// otherwise, ie:
+//
+//	r := progress.NewReader(file, size, progress.NewRenderer(os.Stderr, "copy").Update)
+//	io.Copy(dst, r)
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/dvln/util/units"
+)
+
+// DefaultInterval is the minimum time between progress callbacks
+var DefaultInterval = 200 * time.Millisecond
+
+// Stats is a snapshot of the progress of a transfer
+type Stats struct {
+	Done     int64         // bytes transferred so far
+	Total    int64         // expected total bytes, 0 if unknown
+	Rate     units.Rate    // smoothed transfer rate
+	ETA      time.Duration // estimated time left, only valid if HasETA
+	HasETA   bool          // if an ETA could be estimated
+	Elapsed  time.Duration // time since the transfer started
+	Finished bool          // set on the last callback (EOF, Close or an error)
+	Err      error         // the error that ended the transfer, if any (io.EOF isn't one)
+	Binary   bool          // format sizes in base 1024 (BytesSize) rather than 1000 (HumanSize)
+}
+
+// Callback is called with the progress of a transfer
+type Callback func(Stats)
+
+// Percent returns how much of the total is done (0-100), or -1 if the
+// total isn't known
+func (s Stats) Percent() float64 {
+	if s.Total <= 0 {
+		return -1
+	}
+	pct := float64(s.Done) * 100 / float64(s.Total)
+	if pct > 100 {
+		pct = 100
+	}
+	return pct
+}
+
+// size formats a byte count with units.HumanSize or units.BytesSize
+func (s Stats) size(n int64) string {
+	if s.Binary {
+		return units.BytesSize(float64(n))
+	}
+	return units.HumanSize(float64(n))
+}
+
+// DoneString returns the bytes done formatted, ie: "12.3 MB"
+func (s Stats) DoneString() string {
+	return s.size(s.Done)
+}
+
+// TotalString returns the total formatted, "" if it isn't known
+func (s Stats) TotalString() string {
+	if s.Total <= 0 {
+		return ""
+	}
+	return s.size(s.Total)
+}
+
+// RateString returns the rate formatted, ie: "2.1 MB/s"
+func (s Stats) RateString() string {
+	return s.size(int64(s.Rate)) + "/s"
+}
+
+// ETAString returns the time left formatted with units.HumanDuration, ie:
+// "18 seconds", or "" if it isn't known
+func (s Stats) ETAString() string {
+	if !s.HasETA {
+		return ""
+	}
+	return units.HumanDuration(s.ETA)
+}
+
+// String gives a one line summary, ie: "12.3 MB / 50 MB (24%), 2.1 MB/s,
+// 18 seconds left" (or "..., done in 5 seconds" when finished), the text
+// comes from the current units locale
+func (s Stats) String() string {
+	loc := units.CurrentLocale()
+	str := s.DoneString()
+	if s.Total > 0 {
+		str = loc.Sprintf("progress.total", str, s.TotalString(), fmt.Sprintf("%.0f%%", s.Percent()))
+	}
+	str = loc.Sprintf("progress.rate", str, s.RateString())
+	if s.Finished {
+		return loc.Sprintf("progress.finished", str, loc.HumanDuration(s.Elapsed))
+	}
+	if s.HasETA {
+		str = loc.Sprintf("progress.left", str, s.ETAString())
+	}
+	return str
+}
+
+// tracker does the counting and throttling for Reader and Writer
+type tracker struct {
+	mu       sync.Mutex
+	meter    *units.RateMeter
+	fn       Callback
+	interval time.Duration
+	binary   bool
+	now      func() time.Time
+	start    time.Time
+	last     time.Time
+	finished bool
+}
+
+func newTracker(total int64, fn Callback) *tracker {
+	t := &tracker{meter: units.NewRateMeter(total), fn: fn, interval: DefaultInterval, now: time.Now}
+	t.meter.Now = func() time.Time { return t.now() }
+	return t
+}
+
+// begin starts the clock before the first read or write
+func (t *tracker) begin() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.start.IsZero() {
+		t.start = t.now()
+		t.meter.Start()
+	}
+}
+
+// add counts n more bytes, err is the error (if any) from the underlying
+// read or write, an io.EOF finishes the transfer
+func (t *tracker) add(n int, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.finished {
+		return
+	}
+	t.meter.Add(int64(n))
+	done := t.meter.Count()
+	switch {
+	case err != nil:
+		if err == io.EOF {
+			err = nil
+		}
+		t.finish(err)
+	case t.meter.Total > 0 && done >= t.meter.Total:
+		t.finish(nil)
+	case t.now().Sub(t.last) >= t.interval:
+		t.report(false, nil)
+	}
+}
+
+// finish sends the last callback, t.mu must be held
+func (t *tracker) finish(err error) {
+	if t.finished {
+		return
+	}
+	t.finished = true
+	t.report(true, err)
+}
+
+// report builds the stats and calls back, t.mu must be held
+func (t *tracker) report(finished bool, err error) {
+	now := t.now()
+	t.last = now
+	if t.fn == nil {
+		return
+	}
+	m := t.meter
+	stats := Stats{
+		Done:     m.Count(),
+		Total:    m.Total,
+		Rate:     m.Smoothed(),
+		Finished: finished,
+		Err:      err,
+		Binary:   t.binary,
+	}
+	if finished {
+		stats.Rate = m.Average()
+	}
+	stats.ETA, stats.HasETA = m.Remaining()
+	if !t.start.IsZero() {
+		stats.Elapsed = now.Sub(t.start)
+	}
+	t.fn(stats)
+}
+
+// Reader wraps an io.Reader and reports the progress of reading from it,
+// the final callback happens at EOF, on a read error or on Close()
+type Reader struct {
+	r io.Reader
+	t *tracker
+}
+
+// NewReader wraps r, total is the expected number of bytes (0 if not
+// known) and fn is called at most every DefaultInterval (see SetInterval)
+func NewReader(r io.Reader, total int64, fn Callback) *Reader {
+	return &Reader{r: r, t: newTracker(total, fn)}
+}
+
+// SetInterval changes the minimum time between callbacks
+func (r *Reader) SetInterval(d time.Duration) *Reader {
+	r.t.interval = d
+	return r
+}
+
+// SetBinary formats sizes in base 1024 (units.BytesSize)
+func (r *Reader) SetBinary(binary bool) *Reader {
+	r.t.binary = binary
+	return r
+}
+
+// Read reads from the wrapped reader, counting the bytes
+func (r *Reader) Read(p []byte) (int, error) {
+	r.t.begin()
+	n, err := r.r.Read(p)
+	r.t.add(n, err)
+	return n, err
+}
+
+// Close sends the final callback (if it hasn't happened yet) and closes
+// the wrapped reader if it's an io.Closer
+func (r *Reader) Close() error {
+	var err error
+	if c, ok := r.r.(io.Closer); ok {
+		err = c.Close()
+	}
+	r.t.mu.Lock()
+	r.t.finish(err)
+	r.t.mu.Unlock()
+	return err
+}
+
+// Writer wraps an io.Writer and reports the progress of writing to it,
+// the final callback happens once total bytes are written, on a write
+// error or on Close()
+type Writer struct {
+	w io.Writer
+	t *tracker
+}
+
+// NewWriter wraps w, total is the expected number of bytes (0 if not
+// known) and fn is called at most every DefaultInterval (see SetInterval)
+func NewWriter(w io.Writer, total int64, fn Callback) *Writer {
+	return &Writer{w: w, t: newTracker(total, fn)}
+}
+
+// SetInterval changes the minimum time between callbacks
+func (w *Writer) SetInterval(d time.Duration) *Writer {
+	w.t.interval = d
+	return w
+}
+
+// SetBinary formats sizes in base 1024 (units.BytesSize)
+func (w *Writer) SetBinary(binary bool) *Writer {
+	w.t.binary = binary
+	return w
+}
+
+// Write writes to the wrapped writer, counting the bytes
+func (w *Writer) Write(p []byte) (int, error) {
+	w.t.begin()
+	n, err := w.w.Write(p)
+	w.t.add(n, err)
+	return n, err
+}
+
+// Close sends the final callback (if it hasn't happened yet) and closes
+// the wrapped writer if it's an io.Closer
+func (w *Writer) Close() error {
+	var err error
+	if c, ok := w.w.(io.Closer); ok {
+		err = c.Close()
+	}
+	w.t.mu.Lock()
+	w.t.finish(err)
+	w.t.mu.Unlock()
+	return err
+}
@@ -0,0 +1,218 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dvln/util/units"
+)
+
+// fakeClock is a clock that tests move along by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// slowReader hands out chunk bytes per read and moves the clock on a
+// second each time
+type slowReader struct {
+	data  []byte
+	chunk int
+	clock *fakeClock
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	if len(s.data) == 0 {
+		return 0, io.EOF
+	}
+	s.clock.now = s.clock.now.Add(time.Second)
+	n := copy(p[:s.chunk], s.data)
+	s.data = s.data[n:]
+	return n, nil
+}
+
+func TestReader(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)}
+	src := &slowReader{data: bytes.Repeat([]byte("x"), 5000), chunk: 1000, clock: clock}
+	calls := []Stats{}
+	r := NewReader(src, 5000, func(s Stats) { calls = append(calls, s) })
+	r.t.now = clock.Now
+
+	n, err := io.Copy(ioutil.Discard, r)
+	if err != nil || n != 5000 {
+		t.Fatalf("copy failed: %d bytes, %v", n, err)
+	}
+	if len(calls) != 5 {
+		t.Fatalf("expected 5 callbacks, got %d: %v", len(calls), calls)
+	}
+	first, last := calls[0], calls[4]
+	if first.Done != 1000 || first.Finished {
+		t.Fatalf("unexpected first callback: %+v", first)
+	}
+	if last.Done != 5000 || !last.Finished || last.Err != nil {
+		t.Fatalf("unexpected last callback: %+v", last)
+	}
+	if last.Percent() != 100 {
+		t.Fatalf("expected 100%%, got %v", last.Percent())
+	}
+	if s := calls[2].String(); s != "3 kB / 5 kB (60%), 1 kB/s, 2 seconds left" {
+		t.Fatalf("unexpected progress line: %q", s)
+	}
+	if s := last.String(); s != "5 kB / 5 kB (100%), 1 kB/s, done in 5 seconds" {
+		t.Fatalf("unexpected final line: %q", s)
+	}
+
+	// closing after the end doesn't call back again
+	r.Close()
+	if len(calls) != 5 {
+		t.Fatalf("expected no callback from Close, got %d", len(calls))
+	}
+}
+
+func TestReaderThrottle(t *testing.T) {
+	calls := 0
+	r := NewReader(strings.NewReader(strings.Repeat("x", 100)), 0, func(s Stats) { calls++ })
+	r.SetInterval(time.Hour)
+	buf := make([]byte, 10)
+	for {
+		if _, err := r.Read(buf); err != nil {
+			break
+		}
+	}
+	// the first read reports (nothing reported yet) then only EOF does
+	if calls != 2 {
+		t.Fatalf("expected 2 callbacks, got %d", calls)
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestWriter(t *testing.T) {
+	var out bytes.Buffer
+	var last Stats
+	w := NewWriter(&out, 0, func(s Stats) { last = s }).SetBinary(true)
+	w.Write(bytes.Repeat([]byte("x"), 1536))
+	if last.DoneString() != "1.5 KiB" || last.TotalString() != "" || last.Percent() != -1 {
+		t.Fatalf("unexpected stats: %+v", last)
+	}
+	if last.Finished {
+		t.Fatalf("writer with no total shouldn't finish before Close")
+	}
+	w.Close()
+	if !last.Finished {
+		t.Fatalf("expected Close to finish the writer")
+	}
+
+	w = NewWriter(failWriter{}, 10, func(s Stats) { last = s })
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Fatalf("expected the write error to come through")
+	}
+	if !last.Finished || last.Err == nil || last.Err.Error() != "disk full" {
+		t.Fatalf("expected a finished callback with the error, got %+v", last)
+	}
+}
+
+func TestRendererLog(t *testing.T) {
+	var out bytes.Buffer
+	clock := &fakeClock{now: time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)}
+	r := NewRenderer(&out, "copy")
+	r.Now = clock.Now
+	if r.TTY {
+		t.Fatalf("a buffer isn't a terminal")
+	}
+	r.Update(Stats{Done: 1000, Total: 4000, Rate: 500, ETA: 6 * time.Second, HasETA: true})
+	clock.now = clock.now.Add(time.Second)
+	r.Update(Stats{Done: 2000, Total: 4000, Rate: 500, ETA: 4 * time.Second, HasETA: true})
+	r.Update(Stats{Done: 4000, Total: 4000, Rate: 1000, Elapsed: 4 * time.Second, Finished: true})
+	expected := "copy: 1 kB / 4 kB (25%), 500 B/s, 6 seconds left\n" +
+		"copy: 4 kB / 4 kB (100%), 1 kB/s, done in 4 seconds\n"
+	if out.String() != expected {
+		t.Fatalf("unexpected log output:\n%s", out.String())
+	}
+}
+
+func TestRendererBar(t *testing.T) {
+	var out bytes.Buffer
+	r := NewRenderer(&out, "")
+	r.TTY = true
+	r.Width = 10
+	r.Update(Stats{Done: 5000, Total: 10000, Rate: 1000, ETA: 5 * time.Second, HasETA: true})
+	r.Update(Stats{Done: 10000, Total: 10000, Rate: 1000, Elapsed: 10 * time.Second, Finished: true})
+	expected := "\r[=====>    ] 5 kB / 10 kB (50%), 1 kB/s, 5 seconds left" +
+		"\r[==========] 10 kB / 10 kB (100%), 1 kB/s, done in 10 seconds\n"
+	if out.String() != expected {
+		t.Fatalf("unexpected bar output: %q", out.String())
+	}
+}
+
+// failCloser is a reader whose Close fails
+type failCloser struct {
+	io.Reader
+}
+
+func (failCloser) Close() error {
+	return errors.New("close failed")
+}
+
+func TestReaderCloseError(t *testing.T) {
+	var last Stats
+	r := NewReader(failCloser{strings.NewReader("abc")}, 3, func(s Stats) { last = s })
+	if err := r.Close(); err == nil || err.Error() != "close failed" {
+		t.Fatalf("expected the Close error to come back, got: %v", err)
+	}
+	if !last.Finished || last.Err == nil {
+		t.Fatalf("expected a finished callback with the Close error, got %+v", last)
+	}
+}
+
+func TestRendererDevNull(t *testing.T) {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skipf("no %s: %s", os.DevNull, err)
+	}
+	defer f.Close()
+	if NewRenderer(f, "copy").TTY {
+		t.Fatalf("%s isn't a terminal", os.DevNull)
+	}
+}
+
+func TestStatsLocale(t *testing.T) {
+	defer units.SetLocale(units.CurrentLocale().Tag)
+	units.SetLocale("de")
+	s := Stats{Done: 3000, Total: 5000, Rate: 1000, ETA: 2 * time.Second, HasETA: true}
+	if str := s.String(); str != "3 kB / 5 kB (60%), 1 kB/s, noch 2 Sekunden" {
+		t.Fatalf("unexpected german summary: %s", str)
+	}
+	s.Finished, s.Elapsed = true, 5*time.Second
+	if str := s.String(); str != "3 kB / 5 kB (60%), 1 kB/s, fertig in 5 Sekunden" {
+		t.Fatalf("unexpected german summary: %s", str)
+	}
+}
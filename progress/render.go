@@ -0,0 +1,131 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Renderer draws progress for a Reader or Writer, pass its Update method
+// as the callback.  On a terminal it redraws a single progress bar line,
+// otherwise (logs, pipes, CI output) it writes a line every LogInterval
+// and one when the transfer finishes.
+type Renderer struct {
+	Out         io.Writer        // where to draw/log the progress
+	Label       string           // prefix for the output, ie: the file name
+	TTY         bool             // draw a bar (true) or log lines (false)
+	Width       int              // width of the bar in characters
+	LogInterval time.Duration    // time between log lines when not on a TTY
+	Now         func() time.Time // clock, time.Now if nil
+
+	mu      sync.Mutex
+	lastLog time.Time
+	lastLen int
+}
+
+// NewRenderer returns a renderer writing to out, it draws a bar if out is
+// a terminal and logs a line every 5 seconds otherwise
+func NewRenderer(out io.Writer, label string) *Renderer {
+	return &Renderer{
+		Out:         out,
+		Label:       label,
+		TTY:         isTerminal(out),
+		Width:       30,
+		LogInterval: 5 * time.Second,
+	}
+}
+
+// isTerminal checks if the writer is a file that's a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	return isTerminalFd(f.Fd())
+}
+
+// Update renders the given progress, it's a Callback
+func (r *Renderer) Update(s Stats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.TTY {
+		r.draw(s)
+		return
+	}
+	now := time.Now()
+	if r.Now != nil {
+		now = r.Now()
+	}
+	if !s.Finished && !r.lastLog.IsZero() && now.Sub(r.lastLog) < r.LogInterval {
+		return
+	}
+	r.lastLog = now
+	fmt.Fprintln(r.Out, r.line(s, ""))
+}
+
+// draw redraws the bar line in place, r.mu must be held
+func (r *Renderer) draw(s Stats) {
+	line := r.line(s, r.bar(s))
+	// pad with spaces to wipe out the end of a longer previous line
+	n := len([]rune(line))
+	pad := ""
+	if n < r.lastLen {
+		pad = strings.Repeat(" ", r.lastLen-n)
+	}
+	r.lastLen = n
+	fmt.Fprint(r.Out, "\r"+line+pad)
+	if s.Finished {
+		fmt.Fprintln(r.Out)
+		r.lastLen = 0
+	}
+}
+
+// line builds the output line, ie: "label: [==>  ] 12.3 MB / 50 MB ..."
+func (r *Renderer) line(s Stats, bar string) string {
+	parts := []string{}
+	if r.Label != "" {
+		parts = append(parts, r.Label+":")
+	}
+	if bar != "" {
+		parts = append(parts, bar)
+	}
+	text := s.String()
+	if s.Err != nil {
+		text += ", failed: " + s.Err.Error()
+	}
+	return strings.Join(append(parts, text), " ")
+}
+
+// bar returns the progress bar, ie: "[=====>    ]", or "" if the total
+// isn't known
+func (r *Renderer) bar(s Stats) string {
+	pct := s.Percent()
+	if pct < 0 || r.Width <= 0 {
+		return ""
+	}
+	filled := int(pct / 100 * float64(r.Width))
+	head := ""
+	if filled < r.Width {
+		head = ">"
+	}
+	rest := r.Width - filled - len(head)
+	return "[" + strings.Repeat("=", filled) + head + strings.Repeat(" ", rest) + "]"
+}
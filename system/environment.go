@@ -0,0 +1,395 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// fsRoot is the root used for the non-/proc files we examine (/.dockerenv,
+// /run/..., etc), tests point this at a fixture tree
+var fsRoot = "/"
+
+// getenv is used for env var lookups so tests can fake the environment
+var getenv = os.Getenv
+
+// Container runtimes that Environment() can identify
+const (
+	ContainerNone       = ""
+	ContainerDocker     = "docker"
+	ContainerPodman     = "podman"
+	ContainerKubernetes = "kubernetes"
+	ContainerLXC        = "lxc"
+	ContainerUnknown    = "unknown"
+)
+
+// cgroupV1Unlimited is what cgroup v1 memory.limit_in_bytes reports (or
+// anything above it) when there is no memory limit set
+const cgroupV1Unlimited = 1 << 62
+
+// EnvInfo describes the environment we're running in, ie: if we are in a
+// container, under WSL or systemd and what cgroup limits apply to us
+type EnvInfo struct {
+	Container      string  // one of the Container* values, ContainerNone if not in one
+	WSL            int     // WSL version (1 or 2) or 0 if not running under WSL
+	Systemd        bool    // system was booted with systemd (it is pid 1)
+	SystemdService bool    // we were started as a systemd unit
+	CgroupVersion  int     // version the memory/cpu controllers are on: 1, 2, or 0 if unknown
+	MemoryLimit    int64   // effective cgroup memory limit in bytes, 0 if none
+	CPULimit       float64 // effective cgroup CPU quota in cores, 0 if none
+}
+
+// InContainer returns true if any container runtime was detected
+func (e *EnvInfo) InContainer() bool {
+	return e.Container != ContainerNone
+}
+
+// Environment examines /proc/1/cgroup, /.dockerenv, /proc/self/mountinfo
+// and the cgroup v1/v2 controller files to figure out what sort of
+// environment we're running in.  Detection is best effort, anything that
+// can't be read is simply not reported.
+func Environment() *EnvInfo {
+	mounts := readMountInfo()
+	env := &EnvInfo{
+		Container:      detectContainer(mounts),
+		WSL:            detectWSL(),
+		Systemd:        detectSystemd(),
+		SystemdService: getenv("INVOCATION_ID") != "",
+	}
+	env.CgroupVersion = cgroupVersion(mounts)
+	env.MemoryLimit, env.CPULimit = cgroupLimits(mounts)
+	return env
+}
+
+// rootPath returns the given absolute path relative to fsRoot
+func rootPath(path string) string {
+	return filepath.Join(fsRoot, path)
+}
+
+// fileExists is a quiet existence check, any error means no
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// readTrimmed returns the whitespace trimmed contents of a file
+func readTrimmed(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// detectContainer checks the runtime marker files, pid 1's cgroups and
+// the mount table for signs of a container
+func detectContainer(mounts []mountInfo) string {
+	// kubernetes first as it's usually running docker/containerd below it
+	if getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return ContainerKubernetes
+	}
+	cgroups, _ := ioutil.ReadFile(filepath.Join(procRoot, "1", "cgroup"))
+	cg := string(cgroups)
+	switch {
+	case strings.Contains(cg, "kubepods"):
+		return ContainerKubernetes
+	case fileExists(rootPath("/run/.containerenv")) || strings.Contains(cg, "libpod"):
+		return ContainerPodman
+	case fileExists(rootPath("/.dockerenv")) || strings.Contains(cg, "/docker"):
+		return ContainerDocker
+	case strings.Contains(cg, "/lxc") || getenv("container") == "lxc":
+		return ContainerLXC
+	}
+	// with cgroup namespaces /proc/1/cgroup is just "0::/", but the runtime
+	// bind mounts (resolv.conf, hostname) still give the game away
+	for _, m := range mounts {
+		switch {
+		case strings.Contains(m.root, "/kubelet/pods/"):
+			return ContainerKubernetes
+		case strings.Contains(m.root, "/containers/storage/"):
+			return ContainerPodman
+		case strings.Contains(m.root, "/docker/containers/"):
+			return ContainerDocker
+		}
+	}
+	if getenv("container") != "" {
+		return ContainerUnknown
+	}
+	return ContainerNone
+}
+
+// detectWSL looks at the kernel release string, WSL1 uses "Microsoft" and
+// WSL2 uses "microsoft-standard" in the kernel release
+func detectWSL() int {
+	release, err := readTrimmed(filepath.Join(procRoot, "sys", "kernel", "osrelease"))
+	if err != nil {
+		return 0
+	}
+	switch {
+	case strings.Contains(release, "Microsoft"):
+		return 1
+	case strings.Contains(strings.ToLower(release), "microsoft"):
+		return 2
+	}
+	return 0
+}
+
+// detectSystemd uses the same check as sd_booted(3), falling back to the
+// name of pid 1
+func detectSystemd() bool {
+	if fileExists(rootPath("/run/systemd/system")) {
+		return true
+	}
+	comm, err := readTrimmed(filepath.Join(procRoot, "1", "comm"))
+	return err == nil && comm == "systemd"
+}
+
+// mountInfo holds the fields we use from a /proc/self/mountinfo line
+type mountInfo struct {
+	root       string // root of the mount within its filesystem
+	mountPoint string
+	fsType     string
+	superOpts  []string
+}
+
+// readMountInfo parses /proc/self/mountinfo, see proc(5) for the format,
+// the optional fields are terminated by a lone "-"
+func readMountInfo() []mountInfo {
+	f, err := os.Open(filepath.Join(procRoot, "self", "mountinfo"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	mounts := []mountInfo{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		sep := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if len(fields) < 5 || sep < 0 || len(fields) < sep+4 {
+			continue
+		}
+		mounts = append(mounts, mountInfo{
+			root:       fields[3],
+			mountPoint: fields[4],
+			fsType:     fields[sep+1],
+			superOpts:  strings.Split(fields[sep+3], ","),
+		})
+	}
+	return mounts
+}
+
+// cgroupVersion reports the cgroup version the memory controller (or the
+// cpu one if memory isn't enabled) lives on, hybrid setups can have v1
+// mounts (ie: name=systemd) with the controllers on the v2 hierarchy
+func cgroupVersion(mounts []mountInfo) int {
+	if version := controllerVersion("memory", mounts); version != 0 {
+		return version
+	}
+	return controllerVersion("cpu", mounts)
+}
+
+// controllerVersion returns 1 if the controller has a v1 hierarchy
+// mounted, 2 if it's available on the unified hierarchy or 0 if neither
+func controllerVersion(controller string, mounts []mountInfo) int {
+	version := 0
+	for _, m := range mounts {
+		switch {
+		case m.fsType == "cgroup" && hasOpt(m.superOpts, controller):
+			return 1
+		case m.fsType == "cgroup2" && version == 0:
+			// cgroup.controllers lists what's enabled, if we can't read it
+			// assume the controller is there
+			data, err := ioutil.ReadFile(filepath.Join(rootPath(m.mountPoint), "cgroup.controllers"))
+			if err != nil || hasOpt(strings.Fields(string(data)), controller) {
+				version = 2
+			}
+		}
+	}
+	return version
+}
+
+// selfCgroups maps controller name to cgroup path from /proc/self/cgroup,
+// the v2 unified hierarchy is stored under ""
+func selfCgroups() map[string]string {
+	data, err := ioutil.ReadFile(filepath.Join(procRoot, "self", "cgroup"))
+	if err != nil {
+		return nil
+	}
+	cgroups := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "" {
+			cgroups[""] = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			cgroups[controller] = parts[2]
+		}
+	}
+	return cgroups
+}
+
+// cgroupDir finds the directory holding our cgroup files for the given
+// controller and the mount point it lives under, translating our cgroup
+// path through the mount's root so it works inside a container that can
+// only see part of the tree
+func cgroupDir(version int, controller string, mounts []mountInfo, cgroups map[string]string) (string, string) {
+	if version == 0 {
+		return "", ""
+	}
+	key := controller
+	if version == 2 {
+		key = ""
+	}
+	cgPath, ok := cgroups[key]
+	if !ok {
+		return "", ""
+	}
+	for _, m := range mounts {
+		if version == 2 && m.fsType != "cgroup2" {
+			continue
+		}
+		if version == 1 && (m.fsType != "cgroup" || !hasOpt(m.superOpts, controller)) {
+			continue
+		}
+		rel := cgPath
+		if m.root != "/" {
+			rel = strings.TrimPrefix(cgPath, m.root)
+		}
+		root := rootPath(m.mountPoint)
+		dir := rootPath(filepath.Join(m.mountPoint, rel))
+		if fileExists(dir) {
+			return dir, root
+		}
+		// path isn't visible (ie: no cgroup namespace), use the mount itself
+		return root, root
+	}
+	return "", ""
+}
+
+// walkCgroup calls fn for dir and each of its parents up to (and
+// including) the mount root, limits set higher up apply to us too
+func walkCgroup(dir, root string, fn func(string)) {
+	for dir != "" {
+		fn(dir)
+		if dir == root || !strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// hasOpt checks if the given option is in the list
+func hasOpt(opts []string, opt string) bool {
+	for _, o := range opts {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// cgroupLimits returns the memory limit in bytes and the CPU limit in
+// cores that apply to our cgroup, the lowest one set anywhere between our
+// cgroup and the root, 0 meaning no limit
+func cgroupLimits(mounts []mountInfo) (int64, float64) {
+	cgroups := selfCgroups()
+	var mem int64
+	var cpu float64
+	memVersion := controllerVersion("memory", mounts)
+	if dir, root := cgroupDir(memVersion, "memory", mounts, cgroups); dir != "" {
+		walkCgroup(dir, root, func(d string) {
+			if limit := memoryLimit(memVersion, d); limit > 0 && (mem == 0 || limit < mem) {
+				mem = limit
+			}
+		})
+	}
+	cpuVersion := controllerVersion("cpu", mounts)
+	if dir, root := cgroupDir(cpuVersion, "cpu", mounts, cgroups); dir != "" {
+		walkCgroup(dir, root, func(d string) {
+			if limit := cpuLimit(cpuVersion, d); limit > 0 && (cpu == 0 || limit < cpu) {
+				cpu = limit
+			}
+		})
+	}
+	return mem, cpu
+}
+
+// memoryLimit reads the memory limit set in a single cgroup dir, 0 if none
+func memoryLimit(version int, dir string) int64 {
+	file := "memory.max"
+	if version == 1 {
+		file = "memory.limit_in_bytes"
+	}
+	val, err := readTrimmed(filepath.Join(dir, file))
+	if err != nil || val == "max" {
+		return 0
+	}
+	limit, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || (version == 1 && limit >= cgroupV1Unlimited) {
+		return 0
+	}
+	return limit
+}
+
+// cpuLimit reads the CPU quota (in cores) set in a single cgroup dir, 0 if
+// none
+func cpuLimit(version int, dir string) float64 {
+	if version == 1 {
+		quota, qerr := readTrimmed(filepath.Join(dir, "cpu.cfs_quota_us"))
+		period, perr := readTrimmed(filepath.Join(dir, "cpu.cfs_period_us"))
+		if qerr != nil || perr != nil {
+			return 0
+		}
+		return cpuQuota(quota, period)
+	}
+	val, err := readTrimmed(filepath.Join(dir, "cpu.max"))
+	if err != nil {
+		return 0
+	}
+	if fields := strings.Fields(val); len(fields) == 2 {
+		return cpuQuota(fields[0], fields[1])
+	}
+	return 0
+}
+
+// cpuQuota turns a CFS quota and period (in usecs) into a number of cores,
+// a quota of "max" (v2) or -1 (v1) means no limit
+func cpuQuota(quota, period string) float64 {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0
+	}
+	return q / p
+}
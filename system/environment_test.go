@@ -0,0 +1,183 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// envFixture builds a fake root fs (with proc under it) from the given
+// files and env, runs Environment() against it and restores everything
+func envFixture(t *testing.T, files map[string]string, env map[string]string) *EnvInfo {
+	tempFolder, err := ioutil.TempDir("", "dvln-util-system-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempFolder)
+	writeFixture(t, tempFolder, files)
+	origProc, origRoot, origEnv := procRoot, fsRoot, getenv
+	defer func() {
+		procRoot, fsRoot, getenv = origProc, origRoot, origEnv
+	}()
+	procRoot = filepath.Join(tempFolder, "proc")
+	fsRoot = tempFolder
+	getenv = func(key string) string { return env[key] }
+	return Environment()
+}
+
+// Docker on a cgroup v1 host with memory and cpu limits
+func TestEnvironmentDockerV1(t *testing.T) {
+	env := envFixture(t, map[string]string{
+		".dockerenv":  "",
+		"proc/1/comm": "sh\n",
+		"proc/1/cgroup": "12:memory:/docker/abc123\n" +
+			"11:cpu,cpuacct:/docker/abc123\n",
+		"proc/self/cgroup": "12:memory:/docker/abc123\n" +
+			"11:cpu,cpuacct:/docker/abc123\n" +
+			"0::/system.slice/containerd.service\n",
+		"proc/self/mountinfo": "650 600 0:52 / / rw,relatime - overlay overlay rw,lowerdir=/x\n" +
+			"700 650 0:60 /docker/abc123 /sys/fs/cgroup/memory ro,nosuid - cgroup cgroup rw,memory\n" +
+			"701 650 0:61 /docker/abc123 /sys/fs/cgroup/cpu,cpuacct ro,nosuid - cgroup cgroup rw,cpu,cpuacct\n",
+		"sys/fs/cgroup/memory/memory.limit_in_bytes":  "536870912\n",
+		"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  "150000\n",
+		"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": "100000\n",
+	}, nil)
+	if env.Container != ContainerDocker || !env.InContainer() {
+		t.Fatalf("Expected to detect docker, got: %+v", env)
+	}
+	if env.CgroupVersion != 1 {
+		t.Fatalf("Expected cgroup v1, got: %d", env.CgroupVersion)
+	}
+	if env.MemoryLimit != 512*1024*1024 || env.CPULimit != 1.5 {
+		t.Fatalf("Expected 512MiB memory and 1.5 cpu limits, got %d and %v", env.MemoryLimit, env.CPULimit)
+	}
+	if env.Systemd || env.WSL != 0 {
+		t.Fatalf("Should not have detected systemd or WSL: %+v", env)
+	}
+}
+
+// Kubernetes pod on a cgroup v2 host with a cgroup namespace
+func TestEnvironmentKubernetesV2(t *testing.T) {
+	env := envFixture(t, map[string]string{
+		"proc/1/cgroup":    "0::/\n",
+		"proc/self/cgroup": "0::/\n",
+		"proc/self/mountinfo": "1200 1100 0:300 / / rw - overlay overlay rw\n" +
+			"1210 1200 0:301 / /sys/fs/cgroup ro - cgroup2 cgroup rw\n" +
+			"1220 1200 8:1 /var/lib/kubelet/pods/1234/etc-hosts /etc/hosts rw - ext4 /dev/sda1 rw\n",
+		"sys/fs/cgroup/memory.max": "1073741824\n",
+		"sys/fs/cgroup/cpu.max":    "max 100000\n",
+	}, map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"})
+	if env.Container != ContainerKubernetes {
+		t.Fatalf("Expected to detect kubernetes, got: %+v", env)
+	}
+	if env.CgroupVersion != 2 || env.MemoryLimit != 1024*1024*1024 || env.CPULimit != 0 {
+		t.Fatalf("Expected cgroup v2 with 1GiB memory and no cpu limit, got: %+v", env)
+	}
+}
+
+// Podman detected from the mount table alone
+func TestEnvironmentPodmanMounts(t *testing.T) {
+	env := envFixture(t, map[string]string{
+		"proc/1/cgroup": "0::/\n",
+		"proc/self/mountinfo": "50 40 0:30 / / rw - overlay overlay rw\n" +
+			"51 50 0:31 /containers/storage/overlay-containers/abc/userdata/hostname /etc/hostname rw - tmpfs tmpfs rw\n",
+	}, nil)
+	if env.Container != ContainerPodman {
+		t.Fatalf("Expected to detect podman, got: %+v", env)
+	}
+}
+
+// Plain systemd host on cgroup v2 running a service with a cpu quota
+func TestEnvironmentSystemdHost(t *testing.T) {
+	env := envFixture(t, map[string]string{
+		"run/systemd/system/.keep": "",
+		"proc/1/comm":              "systemd\n",
+		"proc/1/cgroup":            "0::/init.scope\n",
+		"proc/self/cgroup":         "0::/system.slice/dvln.service\n",
+		"proc/self/mountinfo": "22 1 8:1 / / rw - ext4 /dev/sda1 rw\n" +
+			"30 22 0:26 / /sys/fs/cgroup rw,nosuid shared:4 - cgroup2 cgroup2 rw,nsdelegate\n",
+		"sys/fs/cgroup/system.slice/dvln.service/memory.max": "max\n",
+		"sys/fs/cgroup/system.slice/dvln.service/cpu.max":    "50000 100000\n",
+		"proc/sys/kernel/osrelease":                          "6.1.0-13-amd64\n",
+	}, map[string]string{"INVOCATION_ID": "abcdef"})
+	if env.InContainer() || env.WSL != 0 {
+		t.Fatalf("Should not have detected a container or WSL: %+v", env)
+	}
+	if !env.Systemd || !env.SystemdService {
+		t.Fatalf("Expected systemd and a systemd service: %+v", env)
+	}
+	if env.CgroupVersion != 2 || env.MemoryLimit != 0 || env.CPULimit != 0.5 {
+		t.Fatalf("Expected cgroup v2, no memory limit and 0.5 cpu limit, got: %+v", env)
+	}
+}
+
+// Limits set on a parent cgroup apply if they are lower than our own
+func TestEnvironmentNestedLimits(t *testing.T) {
+	env := envFixture(t, map[string]string{
+		"proc/self/cgroup": "0::/user.slice/build.slice/job.scope\n",
+		"proc/self/mountinfo": "22 1 8:1 / / rw - ext4 /dev/sda1 rw\n" +
+			"30 22 0:26 / /sys/fs/cgroup rw - cgroup2 cgroup2 rw\n",
+		"sys/fs/cgroup/cgroup.controllers":                          "cpuset cpu io memory pids\n",
+		"sys/fs/cgroup/user.slice/memory.max":                       "max\n",
+		"sys/fs/cgroup/user.slice/build.slice/memory.max":           "268435456\n",
+		"sys/fs/cgroup/user.slice/build.slice/cpu.max":              "200000 100000\n",
+		"sys/fs/cgroup/user.slice/build.slice/job.scope/memory.max": "1073741824\n",
+		"sys/fs/cgroup/user.slice/build.slice/job.scope/cpu.max":    "max 100000\n",
+		"sys/fs/cgroup/user.slice/cpu.max":                          "400000 100000\n",
+	}, nil)
+	if env.CgroupVersion != 2 || env.MemoryLimit != 256*1024*1024 || env.CPULimit != 2 {
+		t.Fatalf("Expected the parent 256MiB memory and 2 cpu limits, got: %+v", env)
+	}
+}
+
+// Hybrid host: a v1 name=systemd mount but the controllers are on v2
+func TestEnvironmentHybrid(t *testing.T) {
+	env := envFixture(t, map[string]string{
+		"proc/self/cgroup": "1:name=systemd:/system.slice/dvln.service\n" +
+			"0::/system.slice/dvln.service\n",
+		"proc/self/mountinfo": "22 1 8:1 / / rw - ext4 /dev/sda1 rw\n" +
+			"30 22 0:26 / /sys/fs/cgroup/unified rw - cgroup2 cgroup2 rw\n" +
+			"31 22 0:27 / /sys/fs/cgroup/systemd rw - cgroup cgroup rw,xattr,name=systemd\n",
+		"sys/fs/cgroup/unified/cgroup.controllers":                   "cpu memory\n",
+		"sys/fs/cgroup/unified/system.slice/dvln.service/memory.max": "536870912\n",
+		"sys/fs/cgroup/unified/system.slice/dvln.service/cpu.max":    "50000 100000\n",
+	}, nil)
+	if env.CgroupVersion != 2 || env.MemoryLimit != 512*1024*1024 || env.CPULimit != 0.5 {
+		t.Fatalf("Expected v2 controllers with 512MiB memory and 0.5 cpu limits, got: %+v", env)
+	}
+}
+
+// WSL1 and WSL2 kernel release strings
+func TestEnvironmentWSL(t *testing.T) {
+	env := envFixture(t, map[string]string{
+		"proc/sys/kernel/osrelease": "5.15.90.1-microsoft-standard-WSL2\n",
+	}, nil)
+	if env.WSL != 2 {
+		t.Fatalf("Expected WSL 2, got %d", env.WSL)
+	}
+	env = envFixture(t, map[string]string{
+		"proc/sys/kernel/osrelease": "4.4.0-19041-Microsoft\n",
+	}, nil)
+	if env.WSL != 1 {
+		t.Fatalf("Expected WSL 1, got %d", env.WSL)
+	}
+	if env.InContainer() || env.CgroupVersion != 0 {
+		t.Fatalf("Expected no container or cgroup details: %+v", env)
+	}
+}
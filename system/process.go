@@ -0,0 +1,227 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package system contains routines for common system level examination,
+// such as looking at running processes (ie: is a lock holder still alive)
+// or figuring out if we're in a container, all via the /proc filesystem.
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dvln/out"
+)
+
+// procRoot is where the proc filesystem is mounted, tests point this at
+// a fixture tree instead of the live /proc
+var procRoot = "/proc"
+
+// clockTicks is the kernel USER_HZ value used for the start time fields in
+// /proc/<pid>/stat, it is 100 on every Linux platform we care about
+const clockTicks = 100
+
+// ProcessInfo holds the diagnostic details we can dig out of /proc for
+// a single process
+type ProcessInfo struct {
+	PID       int
+	PPID      int
+	State     string
+	Cmdline   []string
+	Exe       string
+	Cwd       string
+	StartTime time.Time
+	RSS       int64 // resident set size in bytes
+	OpenFDs   int   // -1 if the fd dir couldn't be read (ie: permissions)
+}
+
+// Process returns the command line, executable path, cwd, parent PID,
+// start time, RSS and open file descriptor count for the given pid. The
+// exe, cwd and fd details of other users processes are usually not readable,
+// those are left empty (or -1 for OpenFDs) rather than failing the call.
+func Process(pid int) (*ProcessInfo, error) {
+	pidDir := filepath.Join(procRoot, strconv.Itoa(pid))
+	st, err := readStat(pidDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, out.NewErr(fmt.Sprintf("No process found with pid %d", pid), 4015)
+		}
+		return nil, out.WrapErr(err, fmt.Sprintf("Failed to read process stat for pid %d", pid), 4016)
+	}
+	info := &ProcessInfo{
+		PID:     pid,
+		PPID:    st.ppid,
+		State:   st.state,
+		OpenFDs: -1,
+	}
+	if btime, err := bootTime(); err == nil {
+		info.StartTime = btime.Add(ticksToDuration(st.startTicks))
+	}
+	if cmdline, err := ioutil.ReadFile(filepath.Join(pidDir, "cmdline")); err == nil {
+		info.Cmdline = splitCmdline(cmdline)
+	}
+	if exe, err := os.Readlink(filepath.Join(pidDir, "exe")); err == nil {
+		info.Exe = exe
+	}
+	if cwd, err := os.Readlink(filepath.Join(pidDir, "cwd")); err == nil {
+		info.Cwd = cwd
+	}
+	if rss, err := readRSS(pidDir); err == nil {
+		info.RSS = rss
+	}
+	if fds, err := ioutil.ReadDir(filepath.Join(pidDir, "fd")); err == nil {
+		info.OpenFDs = len(fds)
+	}
+	return info, nil
+}
+
+// procAlive checks the pid via /proc, zombies (exited but not yet reaped)
+// are not considered alive, ok is false if there is no /proc to look at
+func procAlive(pid int) (alive bool, ok bool) {
+	if _, err := os.Stat(filepath.Join(procRoot, "self")); err != nil {
+		return false, false
+	}
+	st, err := readStat(filepath.Join(procRoot, strconv.Itoa(pid)))
+	if err != nil {
+		return false, true
+	}
+	return st.state != "Z" && st.state != "X", true
+}
+
+// Children returns the pids of the direct children of the given pid,
+// sorted in ascending order (an empty list if there are none)
+func Children(pid int) ([]int, error) {
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return nil, out.WrapErr(err, "Failed to scan proc filesystem for child processes", 4017)
+	}
+	children := []int{}
+	for _, entry := range entries {
+		child, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		st, err := readStat(filepath.Join(procRoot, entry.Name()))
+		if err != nil {
+			// process went away while we were scanning, skip it
+			continue
+		}
+		if st.ppid == pid {
+			children = append(children, child)
+		}
+	}
+	sort.Ints(children)
+	return children, nil
+}
+
+// procStat holds the few fields we use from /proc/<pid>/stat
+type procStat struct {
+	state      string
+	ppid       int
+	startTicks int64
+}
+
+// readStat parses /proc/<pid>/stat, the command name (2nd field) is in
+// parens and may itself contain spaces or parens so we split after the
+// last closing paren
+func readStat(pidDir string) (*procStat, error) {
+	data, err := ioutil.ReadFile(filepath.Join(pidDir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	end := bytes.LastIndexByte(data, ')')
+	if end < 0 {
+		return nil, fmt.Errorf("malformed stat file in %s", pidDir)
+	}
+	// fields after the command name start with the state (field 3)
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 20 {
+		return nil, fmt.Errorf("truncated stat file in %s", pidDir)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("bad ppid in stat file in %s: %s", pidDir, err)
+	}
+	startTicks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("bad start time in stat file in %s: %s", pidDir, err)
+	}
+	return &procStat{state: fields[0], ppid: ppid, startTicks: startTicks}, nil
+}
+
+// ticksToDuration converts clock ticks to a duration, splitting off whole
+// seconds first so ticks*1e9 can't overflow on long running hosts
+func ticksToDuration(ticks int64) time.Duration {
+	return time.Duration(ticks/clockTicks)*time.Second + time.Duration(ticks%clockTicks)*(time.Second/clockTicks)
+}
+
+// readRSS returns the resident set size in bytes from /proc/<pid>/statm,
+// where the 2nd field is the resident page count
+func readRSS(pidDir string) (int64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(pidDir, "statm"))
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed statm file in %s", pidDir)
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return pages * int64(os.Getpagesize()), nil
+}
+
+// bootTime reads the system boot time ("btime" line) from /proc/stat
+func bootTime() (time.Time, error) {
+	f, err := os.Open(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "btime" {
+			secs, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return time.Time{}, err
+	}
+	return time.Time{}, fmt.Errorf("no btime entry in %s/stat", procRoot)
+}
+
+// splitCmdline turns the NUL separated /proc/<pid>/cmdline into args
+func splitCmdline(data []byte) []string {
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(string(data), "\x00")
+}
@@ -0,0 +1,28 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !unix && !windows
+
+package system
+
+// ProcessAlive returns true if the given pid is a running process, on
+// this platform it can only tell via /proc (false if there isn't one)
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	alive, _ := procAlive(pid)
+	return alive
+}
@@ -0,0 +1,187 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// writeFixture creates the given files (relative path -> content) under root
+func writeFixture(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		full := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// makeProcFixture sets up a fake /proc with a parent (100), two children
+// (200 and 300, the latter a zombie) and points procRoot at it
+func makeProcFixture(t *testing.T) (string, func()) {
+	tempFolder, err := ioutil.TempDir("", "dvln-util-system-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFixture(t, tempFolder, map[string]string{
+		"self/stat":   "1 (init) S 0 1 1 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 5 0 0\n",
+		"stat":        "cpu  1 2 3 4\nbtime 1600000000\nprocesses 42\n",
+		"100/stat":    "100 (dvln (main)) S 1 100 100 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 1500 1000 25\n",
+		"100/statm":   "1000 25 10 1 0 100 0\n",
+		"100/cmdline": "dvln\x00get\x00--verbose\x00",
+		"100/fd/0":    "",
+		"100/fd/1":    "",
+		"100/fd/2":    "",
+		"200/stat":    "200 (git) R 100 100 100 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 1600 1000 25\n",
+		"300/stat":    "300 (sh) Z 100 100 100 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 1700 0 0\n",
+		"notapid/x":   "",
+	})
+	os.Symlink("/usr/bin/dvln", filepath.Join(tempFolder, "100", "exe"))
+	os.Symlink("/home/me/ws", filepath.Join(tempFolder, "100", "cwd"))
+	origRoot := procRoot
+	procRoot = tempFolder
+	return tempFolder, func() {
+		procRoot = origRoot
+		os.RemoveAll(tempFolder)
+	}
+}
+
+// Process details from a fixture /proc tree
+func TestProcessFixture(t *testing.T) {
+	_, cleanup := makeProcFixture(t)
+	defer cleanup()
+
+	info, err := Process(100)
+	if err != nil {
+		t.Fatalf("Unexpected error getting process info: %s", err)
+	}
+	if info.PPID != 1 || info.State != "S" {
+		t.Fatalf("Expected ppid 1 and state S, got ppid %d and state %s", info.PPID, info.State)
+	}
+	if !reflect.DeepEqual(info.Cmdline, []string{"dvln", "get", "--verbose"}) {
+		t.Fatalf("Unexpected command line: %q", info.Cmdline)
+	}
+	if info.Exe != "/usr/bin/dvln" || info.Cwd != "/home/me/ws" {
+		t.Fatalf("Unexpected exe (%s) or cwd (%s)", info.Exe, info.Cwd)
+	}
+	if info.RSS != 25*int64(os.Getpagesize()) {
+		t.Fatalf("Unexpected RSS: %d", info.RSS)
+	}
+	if info.OpenFDs != 3 {
+		t.Fatalf("Expected 3 open fds, found %d", info.OpenFDs)
+	}
+	expStart := time.Unix(1600000000+15, 0)
+	if !info.StartTime.Equal(expStart) {
+		t.Fatalf("Expected start time %s, got %s", expStart, info.StartTime)
+	}
+
+	// missing details (no exe/cwd/fd access) should not fail the call
+	info, err = Process(200)
+	if err != nil {
+		t.Fatalf("Unexpected error getting process info: %s", err)
+	}
+	if info.Exe != "" || info.OpenFDs != -1 || info.Cmdline != nil {
+		t.Fatalf("Expected empty details for pid 200, got: %+v", info)
+	}
+
+	if _, err = Process(999); err == nil {
+		t.Fatal("Should have failed to get info for a non-existent pid")
+	}
+}
+
+// Liveness and child lookups from a fixture /proc tree
+func TestProcessAliveAndChildren(t *testing.T) {
+	_, cleanup := makeProcFixture(t)
+	defer cleanup()
+
+	if !ProcessAlive(100) || !ProcessAlive(200) {
+		t.Fatal("Processes 100 and 200 should be alive")
+	}
+	if ProcessAlive(300) {
+		t.Fatal("Zombie process 300 should not be considered alive")
+	}
+	if ProcessAlive(999) || ProcessAlive(0) {
+		t.Fatal("Non-existent pids should not be alive")
+	}
+	children, err := Children(100)
+	if err != nil {
+		t.Fatalf("Unexpected error getting children: %s", err)
+	}
+	if !reflect.DeepEqual(children, []int{200, 300}) {
+		t.Fatalf("Expected children [200 300], got %v", children)
+	}
+	children, err = Children(200)
+	if err != nil || len(children) != 0 {
+		t.Fatalf("Expected no children for pid 200, got %v (err: %v)", children, err)
+	}
+}
+
+// Process info for ourself from the live /proc
+func TestProcessSelf(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("live /proc checks only run on linux")
+	}
+	info, err := Process(os.Getpid())
+	if err != nil {
+		t.Fatalf("Unexpected error getting our own process info: %s", err)
+	}
+	if info.PPID != os.Getppid() {
+		t.Fatalf("Expected ppid %d, got %d", os.Getppid(), info.PPID)
+	}
+	if info.OpenFDs < 1 || info.RSS <= 0 || len(info.Cmdline) == 0 {
+		t.Fatalf("Expected fds, rss and cmdline to be filled in, got: %+v", info)
+	}
+	if time.Since(info.StartTime) < 0 || time.Since(info.StartTime) > time.Hour {
+		t.Fatalf("Unexpected start time for our own process: %s", info.StartTime)
+	}
+	if !ProcessAlive(os.Getpid()) {
+		t.Fatal("We should be alive")
+	}
+	children, err := Children(os.Getppid())
+	if err != nil {
+		t.Fatalf("Unexpected error getting children: %s", err)
+	}
+	found := false
+	for _, pid := range children {
+		if pid == os.Getpid() {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Expected to find ourself (%d) in children of our parent: %v", os.Getpid(), children)
+	}
+}
+
+// Start ticks from a host that's been up for years shouldn't overflow
+func TestTicksToDuration(t *testing.T) {
+	if d := ticksToDuration(150); d != 1500*time.Millisecond {
+		t.Fatalf("Expected 1.5s for 150 ticks, got %s", d)
+	}
+	// ~10 years of uptime at 100Hz, ticks*1e9 is well past 2^63
+	ticks := int64(10 * 365 * 24 * 3600 * clockTicks)
+	if d := ticksToDuration(ticks + 1); d != time.Duration(10*365*24)*time.Hour+10*time.Millisecond {
+		t.Fatalf("Unexpected duration for %d ticks: %s", ticks+1, d)
+	}
+}
@@ -16,31 +16,35 @@
 package units
 
 import (
-	"fmt"
 	"time"
 )
 
 // HumanDuration returns a human-readable approximation of a duration
-// (eg. "About a minute", "4 hours ago", etc.).
+// (eg. "About a minute", "4 hours ago", etc.) in the current locale.
 func HumanDuration(d time.Duration) string {
-	if seconds := int(d.Seconds()); seconds < 1 {
-		return "Less than a second"
+	return CurrentLocale().HumanDuration(d)
+}
+
+// HumanDuration is HumanDuration() rendered through this locales catalog
+func (l *Locale) HumanDuration(d time.Duration) string {
+	if seconds := int64(d.Seconds()); seconds < 1 {
+		return l.Text("duration.less-than-second")
 	} else if seconds < 60 {
-		return fmt.Sprintf("%d seconds", seconds)
-	} else if minutes := int(d.Minutes()); minutes == 1 {
-		return "About a minute"
+		return l.Count("duration.seconds", seconds)
+	} else if minutes := int64(d.Minutes()); minutes == 1 {
+		return l.Text("duration.about-minute")
 	} else if minutes < 60 {
-		return fmt.Sprintf("%d minutes", minutes)
-	} else if hours := int(d.Hours()); hours == 1 {
-		return "About an hour"
+		return l.Count("duration.minutes", minutes)
+	} else if hours := int64(d.Hours()); hours == 1 {
+		return l.Text("duration.about-hour")
 	} else if hours < 48 {
-		return fmt.Sprintf("%d hours", hours)
+		return l.Count("duration.hours", hours)
 	} else if hours < 24*7*2 {
-		return fmt.Sprintf("%d days", hours/24)
+		return l.Count("duration.days", hours/24)
 	} else if hours < 24*30*3 {
-		return fmt.Sprintf("%d weeks", hours/24/7)
+		return l.Count("duration.weeks", hours/24/7)
 	} else if hours < 24*365*2 {
-		return fmt.Sprintf("%d months", hours/24/30)
+		return l.Count("duration.months", hours/24/30)
 	}
-	return fmt.Sprintf("%d years", int(d.Hours())/24/365)
+	return l.Count("duration.years", int64(d.Hours())/24/365)
 }
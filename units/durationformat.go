@@ -0,0 +1,218 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"strings"
+	"time"
+)
+
+// durationUnit is one of the units a DurationFormatter can print, the
+// name is used to find its "unit.<name>" messages in the locale catalog
+type durationUnit struct {
+	size time.Duration
+	name string
+}
+
+// calendarUnits are only used when DurationFormatter.Calendar is set
+var calendarUnits = []durationUnit{
+	{Year, "year"},
+	{Month, "month"},
+	{Week, "week"},
+}
+
+var clockUnits = []durationUnit{
+	{Day, "day"},
+	{time.Hour, "hour"},
+	{time.Minute, "minute"},
+	{time.Second, "second"},
+	{time.Millisecond, "millisecond"},
+	{time.Microsecond, "microsecond"},
+	{time.Nanosecond, "nanosecond"},
+}
+
+// DurationFormatter formats durations precisely ("1h 45m 12s" or "1 hour,
+// 45 minutes, 12 seconds") and times relative to now ("3 minutes ago",
+// "in 2 days"), unlike the coarse single style of HumanDuration().  The
+// zero value gives short names for every unit from days down to seconds.
+type DurationFormatter struct {
+	Long     bool             // long unit names ("1 hour, 45 minutes")
+	MaxUnits int              // only show the N largest non-zero units, 0 for all
+	Smallest time.Duration    // smallest unit shown (default time.Second), less is truncated
+	Calendar bool             // also use years, months (30d) and weeks
+	Now      func() time.Time // clock used by Relative(), time.Now if nil
+	Locale   string           // locale for the unit names, "" for CurrentLocale()
+}
+
+// PreciseDuration formats a duration with short unit names down to the
+// second, eg. "1h 45m 12s"
+func PreciseDuration(d time.Duration) string {
+	return DurationFormatter{}.Format(d)
+}
+
+// RelativeTime describes the given time relative to now with long unit
+// names, eg. "3 minutes ago" or "in 2 days"
+func RelativeTime(t time.Time) string {
+	return DurationFormatter{Long: true, Calendar: true}.Relative(t)
+}
+
+// Format returns the duration broken down into units, negative durations
+// get a leading "-" and anything under the smallest unit is "0s" (or
+// "0 seconds" with long names)
+func (f DurationFormatter) Format(d time.Duration) string {
+	loc := localeOrCurrent(f.Locale)
+	str := f.join(loc, f.parts(loc, d, false))
+	if str == "" {
+		return f.unitString(loc, 0, f.smallestUnit(), false)
+	}
+	if d < 0 {
+		str = "-" + str
+	}
+	return str
+}
+
+// Relative describes the time t relative to now (see the Now field) with
+// tense, eg. "3 minutes ago", "in 2 days" or "just now" if it's within the
+// smallest unit.  If MaxUnits isn't set only the largest unit is shown,
+// the last unit shown is rounded (ie: 1h59m is "2 hours ago").
+func (f DurationFormatter) Relative(t time.Time) string {
+	now := time.Now
+	if f.Now != nil {
+		now = f.Now
+	}
+	if f.MaxUnits == 0 {
+		f.MaxUnits = 1
+	}
+	loc := localeOrCurrent(f.Locale)
+	d := f.round(t.Sub(now()))
+	str := f.join(loc, f.parts(loc, d, true))
+	switch {
+	case str == "":
+		return loc.Text("relative.now")
+	case d < 0:
+		return loc.Sprintf("relative.past", str)
+	}
+	return loc.Sprintf("relative.future", str)
+}
+
+// smallestUnit returns the unit that Smallest maps to
+func (f DurationFormatter) smallestUnit() durationUnit {
+	smallest := f.Smallest
+	if smallest <= 0 {
+		smallest = time.Second
+	}
+	for _, unit := range clockUnits {
+		if unit.size <= smallest {
+			return unit
+		}
+	}
+	return clockUnits[len(clockUnits)-1]
+}
+
+// units returns the units this formatter can use, largest first
+func (f DurationFormatter) units() []durationUnit {
+	if f.Calendar {
+		return append(append([]durationUnit{}, calendarUnits...), clockUnits...)
+	}
+	return clockUnits
+}
+
+// round rounds the duration to the last unit that would be shown when
+// MaxUnits cuts off the lower ones, rounding up can carry into a bigger
+// unit (59m40s becomes 1h) so this repeats until nothing changes
+func (f DurationFormatter) round(d time.Duration) time.Duration {
+	for range f.units() {
+		last, cut := f.lastUnit(d)
+		if !cut {
+			return d
+		}
+		rounded := d.Round(last.size)
+		if rounded == d {
+			return d
+		}
+		d = rounded
+	}
+	return d
+}
+
+// lastUnit returns the last unit parts() would show and whether MaxUnits
+// was what stopped it
+func (f DurationFormatter) lastUnit(d time.Duration) (durationUnit, bool) {
+	smallest := f.smallestUnit()
+	shown := 0
+	for _, unit := range f.units() {
+		if unit.size < smallest.size {
+			break
+		}
+		count := d / unit.size
+		d -= count * unit.size
+		if count == 0 {
+			continue
+		}
+		shown++
+		if f.MaxUnits > 0 && shown == f.MaxUnits {
+			return unit, true
+		}
+	}
+	return smallest, false
+}
+
+// parts breaks the (absolute) duration into formatted unit strings
+func (f DurationFormatter) parts(loc *Locale, d time.Duration, relative bool) []string {
+	units := f.units()
+	smallest := f.smallestUnit()
+	parts := []string{}
+	for _, unit := range units {
+		if unit.size < smallest.size {
+			break
+		}
+		count := int64(d / unit.size)
+		d -= time.Duration(count) * unit.size
+		if count < 0 {
+			count = -count
+		}
+		if count == 0 {
+			continue
+		}
+		parts = append(parts, f.unitString(loc, count, unit, relative))
+		if f.MaxUnits > 0 && len(parts) == f.MaxUnits {
+			break
+		}
+	}
+	return parts
+}
+
+// join puts the unit strings together with the locales list separator
+// (long names) or a space (short names)
+func (f DurationFormatter) join(loc *Locale, parts []string) string {
+	if f.Long {
+		return strings.Join(parts, loc.Text("list.separator"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// unitString formats a count of the given unit, relative phrasing uses
+// the "relative.unit.<name>" messages if the locale has them
+func (f DurationFormatter) unitString(loc *Locale, count int64, unit durationUnit, relative bool) string {
+	key := "unit." + unit.name
+	if !f.Long {
+		key += ".short"
+	}
+	if relative && loc.has("relative."+key) {
+		key = "relative." + key
+	}
+	return loc.Count(key, count)
+}
@@ -0,0 +1,98 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPreciseDuration(t *testing.T) {
+	d := time.Hour + 45*time.Minute + 12*time.Second + 300*time.Millisecond
+	assertEquals(t, "1h 45m 12s", PreciseDuration(d))
+	assertEquals(t, "0s", PreciseDuration(300*time.Millisecond))
+	assertEquals(t, "-2d 3h", PreciseDuration(-(2*Day + 3*time.Hour)))
+	assertEquals(t, "15d", PreciseDuration(15*Day))
+
+	long := DurationFormatter{Long: true}
+	assertEquals(t, "1 hour, 45 minutes, 12 seconds", long.Format(d))
+	assertEquals(t, "1 minute, 1 second", long.Format(61*time.Second))
+	assertEquals(t, "0 seconds", long.Format(0))
+
+	two := DurationFormatter{Long: true, MaxUnits: 2}
+	assertEquals(t, "1 hour, 45 minutes", two.Format(d))
+
+	ms := DurationFormatter{Smallest: time.Millisecond}
+	assertEquals(t, "1h 45m 12s 300ms", ms.Format(d))
+	assertEquals(t, "0ms", ms.Format(time.Microsecond))
+
+	cal := DurationFormatter{Calendar: true}
+	assertEquals(t, "1y 2mo 3w 4d", cal.Format(Year+2*Month+3*Week+4*Day))
+	assertEquals(t, "15d", DurationFormatter{}.Format(2*Week+Day))
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	f := DurationFormatter{Long: true, Calendar: true, Now: clock}
+	assertEquals(t, "3 minutes ago", f.Relative(now.Add(-3*time.Minute-20*time.Second)))
+	assertEquals(t, "in 2 days", f.Relative(now.Add(2*Day+5*time.Hour)))
+	assertEquals(t, "2 hours ago", f.Relative(now.Add(-time.Hour-59*time.Minute)))
+	assertEquals(t, "3 months ago", f.Relative(now.Add(-95*Day)))
+	assertEquals(t, "just now", f.Relative(now.Add(-500*time.Millisecond)))
+	assertEquals(t, "just now", f.Relative(now))
+
+	f.MaxUnits = 2
+	assertEquals(t, "1 hour, 59 minutes ago", f.Relative(now.Add(-time.Hour-59*time.Minute)))
+
+	short := DurationFormatter{Now: clock}
+	assertEquals(t, "in 45s", short.Relative(now.Add(45*time.Second)))
+	assertEquals(t, "1d ago", short.Relative(now.Add(-26*time.Hour)))
+
+	// the default clock is the real one
+	assertEquals(t, "5 minutes ago", RelativeTime(time.Now().Add(-5*time.Minute-time.Second)))
+	assertEquals(t, "in 1 year", RelativeTime(time.Now().Add(Year+Day)))
+}
+
+func TestRelativeRounding(t *testing.T) {
+	now := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+	f := DurationFormatter{Long: true, Now: func() time.Time { return now }}
+	tests := map[time.Duration]string{
+		-time.Hour - 29*time.Minute:      "1 hour ago",
+		-time.Hour - 30*time.Minute:      "2 hours ago",
+		-59*time.Minute - 29*time.Second: "59 minutes ago",
+		-59*time.Minute - 30*time.Second: "1 hour ago",
+		23*time.Hour + 30*time.Minute:    "in 1 day",
+		23*time.Hour + 29*time.Minute:    "in 23 hours",
+		89 * time.Second:                 "in 1 minute",
+		90 * time.Second:                 "in 2 minutes",
+	}
+	for d, expected := range tests {
+		assertEquals(t, expected, f.Relative(now.Add(d)))
+	}
+
+	// rounding up can carry across calendar units too
+	f.Calendar = true
+	assertEquals(t, "1 week ago", f.Relative(now.Add(-6*Day-13*time.Hour)))
+	assertEquals(t, "6 days ago", f.Relative(now.Add(-6*Day-11*time.Hour)))
+	assertEquals(t, "in 1 month", f.Relative(now.Add(4*Week+5*Day)))
+
+	// with two units the second one is rounded
+	f.Calendar, f.MaxUnits = false, 2
+	assertEquals(t, "1 hour, 2 minutes ago", f.Relative(now.Add(-time.Hour-time.Minute-40*time.Second)))
+	assertEquals(t, "2 hours ago", f.Relative(now.Add(-time.Hour-59*time.Minute-40*time.Second)))
+}
@@ -0,0 +1,210 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Calendar style durations, months and years are the same fixed
+// approximations that HumanDuration() uses
+const (
+	Day   = 24 * time.Hour
+	Week  = 7 * Day
+	Month = 30 * Day
+	Year  = 365 * Day
+)
+
+// durationUnits maps the (lower case) unit names ParseDuration accepts
+var durationUnits = map[string]time.Duration{
+	"ns": time.Nanosecond, "nsec": time.Nanosecond, "nanosecond": time.Nanosecond, "nanoseconds": time.Nanosecond,
+	"us": time.Microsecond, "µs": time.Microsecond, "μs": time.Microsecond, "usec": time.Microsecond,
+	"microsecond": time.Microsecond, "microseconds": time.Microsecond,
+	"ms": time.Millisecond, "msec": time.Millisecond, "millisecond": time.Millisecond, "milliseconds": time.Millisecond,
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": Day, "day": Day, "days": Day,
+	"w": Week, "wk": Week, "wks": Week, "week": Week, "weeks": Week,
+	"mo": Month, "mon": Month, "mos": Month, "month": Month, "months": Month,
+	"y": Year, "yr": Year, "yrs": Year, "year": Year, "years": Year,
+}
+
+// ParseDuration parses a duration string, it accepts everything that
+// time.ParseDuration() does ("1h30m", "-1.5s", "300ms") plus days, weeks,
+// months (30 days) and years (365 days) such as "3d", "2w" or "1y6mo",
+// compound phrases like "1 hour 30 minutes" or "2 days, 3 hours and 4
+// mins" and ISO-8601 durations such as "P1DT2H" or "PT0.5S".
+func ParseDuration(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	neg := false
+	if str != "" && (str[0] == '-' || str[0] == '+') {
+		neg = str[0] == '-'
+		str = strings.TrimSpace(str[1:])
+	}
+	if str == "" {
+		return 0, durationErr(s, "empty duration")
+	}
+
+	var total *big.Rat
+	var err error
+	if str[0] == 'P' || str[0] == 'p' {
+		total, err = parseISODuration(str[1:])
+	} else if str == "0" {
+		total = new(big.Rat)
+	} else {
+		total, err = parseHumanDuration(str)
+	}
+	if err != nil {
+		return 0, durationErr(s, err.Error())
+	}
+	if neg {
+		total.Neg(total)
+	}
+	d := new(big.Int).Quo(total.Num(), total.Denom())
+	if !d.IsInt64() {
+		return 0, durationErr(s, "value out of range")
+	}
+	return time.Duration(d.Int64()), nil
+}
+
+// durationErr builds the error returned for a bad duration string
+func durationErr(s, why string) error {
+	return fmt.Errorf("invalid duration '%s': %s", s, why)
+}
+
+// parseHumanDuration handles the Go style and phrase style formats, a
+// series of number+unit pairs optionally separated by spaces, commas
+// or "and"
+func parseHumanDuration(str string) (*big.Rat, error) {
+	total := new(big.Rat)
+	rest := strings.ToLower(str)
+	count := 0
+	for {
+		rest = strings.TrimLeft(rest, " \t,")
+		if strings.HasPrefix(rest, "and ") && count > 0 {
+			rest = strings.TrimLeft(rest[4:], " \t")
+		}
+		if rest == "" {
+			break
+		}
+		i := 0
+		for i < len(rest) && (rest[i] >= '0' && rest[i] <= '9' || rest[i] == '.') {
+			i++
+		}
+		numStr := rest[:i]
+		if numStr == "" || numStr == "." {
+			return nil, fmt.Errorf("expected a number at '%s'", rest)
+		}
+		num, ok := new(big.Rat).SetString(numStr)
+		if !ok {
+			return nil, fmt.Errorf("bad number '%s'", numStr)
+		}
+		rest = strings.TrimLeft(rest[i:], " \t")
+		j := 0
+		for j < len(rest) {
+			r := []rune(rest[j:])[0]
+			if !unicode.IsLetter(r) {
+				break
+			}
+			j += len(string(r))
+		}
+		if j == 0 {
+			return nil, fmt.Errorf("missing unit after '%s'", numStr)
+		}
+		unit, ok := durationUnits[rest[:j]]
+		if !ok {
+			return nil, fmt.Errorf("unknown unit '%s'", rest[:j])
+		}
+		total.Add(total, num.Mul(num, new(big.Rat).SetInt64(int64(unit))))
+		rest = rest[j:]
+		count++
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("no duration found")
+	}
+	return total, nil
+}
+
+// parseISODuration handles an ISO-8601 duration (the part after the "P"),
+// ie: [nY][nM][nW][nD][T[nH][nM][nS]] where the numbers may have
+// fractions using "." or ","
+func parseISODuration(str string) (*big.Rat, error) {
+	total := new(big.Rat)
+	str = strings.ToUpper(str)
+	inTime := false
+	count := 0
+	order := "YMWD"
+	for str != "" {
+		if str[0] == 'T' {
+			if inTime {
+				return nil, fmt.Errorf("repeated 'T' designator")
+			}
+			inTime = true
+			order = "HMS"
+			str = str[1:]
+			if str == "" {
+				return nil, fmt.Errorf("no time components after 'T'")
+			}
+			continue
+		}
+		i := 0
+		for i < len(str) && (str[i] >= '0' && str[i] <= '9' || str[i] == '.' || str[i] == ',') {
+			i++
+		}
+		if i == 0 || i == len(str) {
+			return nil, fmt.Errorf("bad ISO-8601 component at '%s'", str)
+		}
+		num, ok := new(big.Rat).SetString(strings.Replace(str[:i], ",", ".", 1))
+		if !ok {
+			return nil, fmt.Errorf("bad number '%s'", str[:i])
+		}
+		designator := str[i]
+		pos := strings.IndexByte(order, designator)
+		if pos < 0 {
+			return nil, fmt.Errorf("unexpected or out of order designator '%c'", designator)
+		}
+		order = order[pos+1:]
+		var unit time.Duration
+		switch {
+		case !inTime && designator == 'Y':
+			unit = Year
+		case !inTime && designator == 'M':
+			unit = Month
+		case !inTime && designator == 'W':
+			unit = Week
+		case !inTime && designator == 'D':
+			unit = Day
+		case inTime && designator == 'H':
+			unit = time.Hour
+		case inTime && designator == 'M':
+			unit = time.Minute
+		case inTime && designator == 'S':
+			unit = time.Second
+		}
+		total.Add(total, num.Mul(num, new(big.Rat).SetInt64(int64(unit))))
+		str = str[i+1:]
+		count++
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("no ISO-8601 duration components")
+	}
+	return total, nil
+}
@@ -0,0 +1,79 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		// everything time.ParseDuration handles
+		"0":          0,
+		"300ms":      300 * time.Millisecond,
+		"-1.5h":      -90 * time.Minute,
+		"2h45m":      2*time.Hour + 45*time.Minute,
+		"1µs":        time.Microsecond,
+		"1us":        time.Microsecond,
+		"10ns":       10,
+		".5s":        500 * time.Millisecond,
+		"1h0m0.5s":   time.Hour + 500*time.Millisecond,
+		"+5m":        5 * time.Minute,
+		"2562047h":   2562047 * time.Hour,
+		"3d":         3 * Day,
+		"2w":         2 * Week,
+		"1y6mo":      Year + 6*Month,
+		"1.5d":       36 * time.Hour,
+		"1w2d3h":     Week + 2*Day + 3*time.Hour,
+		"3D":         3 * Day,
+		"90 days":    90 * Day,
+		" 10 mins  ": 10 * time.Minute,
+		// phrases
+		"1 hour 30 minutes":          90 * time.Minute,
+		"1 hour, 30 minutes":         90 * time.Minute,
+		"2 days, 3 hours and 4 mins": 2*Day + 3*time.Hour + 4*time.Minute,
+		"1 year 2 months 3 weeks":    Year + 2*Month + 3*Week,
+		"- 1 second":                 -time.Second,
+		"1 Hour and 1 Second":        time.Hour + time.Second,
+		// ISO-8601
+		"P1DT2H":       Day + 2*time.Hour,
+		"PT0.5S":       500 * time.Millisecond,
+		"PT1,5M":       90 * time.Second,
+		"P1Y2M3W4D":    Year + 2*Month + 3*Week + 4*Day,
+		"PT36H":        36 * time.Hour,
+		"P2W":          2 * Week,
+		"-P1D":         -Day,
+		"P1DT1M":       Day + time.Minute,
+		"p1dt12h30m5s": Day + 12*time.Hour + 30*time.Minute + 5*time.Second,
+	}
+	for in, expected := range tests {
+		d, err := ParseDuration(in)
+		if err != nil || d != expected {
+			t.Errorf("ParseDuration(\"%s\") -> expected '%v' but got '%v' with error '%v'", in, expected, d, err)
+		}
+	}
+
+	for _, in := range []string{
+		"", "-", "5", "1h5", "hello", "1 fortnight", "1..5h", "h", "and 5m",
+		"P", "PT", "P1H", "PT1D", "P1D2Y", "P1DT", "PT1HT2M", "P1.5", "PXD",
+		"3000000h", "P300000D", "-9999999999999999999ns",
+	} {
+		if d, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(\"%s\") -> expected error but got '%v'", in, d)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Duration is a time.Duration that accepts the ParseDuration() formats
+// ("3d", "2w", "1 hour 30 minutes", "P1DT2H") as a command line flag
+// (flag.Value, including the pflag Type() method) or in JSON, YAML or any
+// other text based config (eg: "retention: 90d").
+type Duration time.Duration
+
+// Std returns the plain time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String formats the duration compactly using days as the largest unit
+// (eg. "3d12h", "90d", "1m30s") which ParseDuration() reads back exactly
+func (d Duration) String() string {
+	if d == 0 {
+		return "0s"
+	}
+	n := int64(d)
+	var buf bytes.Buffer
+	if n < 0 {
+		buf.WriteByte('-')
+	}
+	for _, unit := range []struct {
+		name string
+		size time.Duration
+	}{
+		{"d", Day}, {"h", time.Hour}, {"m", time.Minute}, {"s", time.Second},
+		{"ms", time.Millisecond}, {"us", time.Microsecond}, {"ns", time.Nanosecond},
+	} {
+		// work on each unit's count separately so MinInt64 can't overflow
+		count := n / int64(unit.size)
+		n -= count * int64(unit.size)
+		if count < 0 {
+			count = -count
+		}
+		if count != 0 {
+			buf.WriteString(strconv.FormatInt(count, 10))
+			buf.WriteString(unit.name)
+		}
+	}
+	return buf.String()
+}
+
+// Set parses the given string into the duration, for flag.Value
+func (d *Duration) Set(value string) error {
+	dur, err := ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(dur)
+	return nil
+}
+
+// Type returns the type name for pflag's flag usage output
+func (d *Duration) Type() string {
+	return "duration"
+}
+
+// MarshalText writes the duration using String()
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a duration string, see ParseDuration()
+func (d *Duration) UnmarshalText(text []byte) error {
+	return d.Set(string(text))
+}
+
+// MarshalJSON writes the duration as a JSON string using String()
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON accepts either a JSON string ("90d") or a plain number
+// of nanoseconds (the way time.Duration is encoded by default), a JSON
+// null leaves the duration untouched like it does for other types
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		return d.Set(str)
+	}
+	ns, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid duration %s: must be a string or an integer number of nanoseconds", data)
+	}
+	*d = Duration(ns)
+	return nil
+}
+
+// MarshalYAML writes the duration as a YAML string using String()
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// UnmarshalYAML accepts either a duration string or a plain number of
+// nanoseconds (this is the yaml.v2 style interface which yaml.v3 also
+// honors)
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var ns int64
+	if err := unmarshal(&ns); err == nil {
+		*d = Duration(ns)
+		return nil
+	}
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	return d.Set(str)
+}
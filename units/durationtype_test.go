@@ -0,0 +1,109 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDurationFlag(t *testing.T) {
+	var retention Duration
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(&retention, "retention", "how long to keep things")
+	if err := fs.Parse([]string{"-retention", "90d"}); err != nil {
+		t.Fatalf("Unexpected flag parse error: %s", err)
+	}
+	assertEquals(t, Duration(90*Day), retention)
+	assertEquals(t, 90*Day, retention.Std())
+	assertEquals(t, "duration", retention.Type())
+	if err := fs.Parse([]string{"-retention", "forever"}); err == nil {
+		t.Fatal("Expected a flag parse error for a bad duration")
+	}
+}
+
+func TestDurationString(t *testing.T) {
+	tests := map[Duration]string{
+		0:                                 "0s",
+		Duration(90 * Day):                "90d",
+		Duration(3*Day + 12*time.Hour):    "3d12h",
+		Duration(90 * time.Second):        "1m30s",
+		Duration(1500 * time.Microsecond): "1ms500us",
+		Duration(-36 * time.Hour):         "-1d12h",
+		Duration(time.Hour + 5):           "1h5ns",
+		Duration(math.MinInt64):           "-106751d23h47m16s854ms775us808ns",
+		Duration(math.MaxInt64):           "106751d23h47m16s854ms775us807ns",
+	}
+	for d, expected := range tests {
+		assertEquals(t, expected, d.String())
+		var back Duration
+		if err := back.UnmarshalText([]byte(d.String())); err != nil || back != d {
+			t.Errorf("UnmarshalText(%s) -> expected '%d' but got '%d' with error '%v'", d, int64(d), int64(back), err)
+		}
+	}
+}
+
+func TestDurationJSON(t *testing.T) {
+	type config struct {
+		Retention Duration `json:"retention"`
+		Timeout   Duration `json:"timeout"`
+	}
+	var cfg config
+	if err := json.Unmarshal([]byte(`{"retention": "1w", "timeout": 30000000000}`), &cfg); err != nil {
+		t.Fatalf("Unexpected JSON unmarshal error: %s", err)
+	}
+	assertEquals(t, Duration(Week), cfg.Retention)
+	assertEquals(t, Duration(30*time.Second), cfg.Timeout)
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected JSON marshal error: %s", err)
+	}
+	assertEquals(t, `{"retention":"7d","timeout":"30s"}`, string(data))
+	if err := json.Unmarshal([]byte(`{"retention": "a while"}`), &cfg); err == nil {
+		t.Error("Expected JSON unmarshal error for a bad duration")
+	}
+	if err := json.Unmarshal([]byte(`{"retention": 1.5}`), &cfg); err == nil {
+		t.Error("Expected JSON unmarshal error for a fractional number")
+	}
+	cfg = config{Retention: Duration(Week)}
+	if err := json.Unmarshal([]byte(`{"retention": null, "timeout": null}`), &cfg); err != nil {
+		t.Fatalf("Unexpected JSON unmarshal error for null: %s", err)
+	}
+	assertEquals(t, Duration(Week), cfg.Retention)
+	assertEquals(t, Duration(0), cfg.Timeout)
+}
+
+func TestDurationYAML(t *testing.T) {
+	var d Duration
+	err := d.UnmarshalYAML(func(v interface{}) error {
+		if p, ok := v.(*string); ok {
+			*p = "P1DT2H"
+			return nil
+		}
+		return json.Unmarshal([]byte(`"x"`), v)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected YAML unmarshal error: %s", err)
+	}
+	assertEquals(t, Duration(26*time.Hour), d)
+	out, _ := d.MarshalYAML()
+	assertEquals(t, "1d2h", out)
+}
@@ -0,0 +1,266 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+)
+
+// PluralCategory is one of the CLDR plural categories, see:
+// http://cldr.unicode.org/index/cldr-spec/plural-rules
+type PluralCategory string
+
+// The CLDR plural categories, every language uses PluralOther and most
+// only use a couple of the others
+const (
+	PluralZero  PluralCategory = "zero"
+	PluralOne   PluralCategory = "one"
+	PluralTwo   PluralCategory = "two"
+	PluralFew   PluralCategory = "few"
+	PluralMany  PluralCategory = "many"
+	PluralOther PluralCategory = "other"
+)
+
+// PluralRule picks the plural category a number belongs to in a language
+type PluralRule func(n float64) PluralCategory
+
+// PluralRuleOneOther is the rule for English, German, Dutch, Swedish and
+// friends: exactly 1 is "one", everything else is "other"
+func PluralRuleOneOther(n float64) PluralCategory {
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// PluralRuleOther is the rule for languages without plural forms, such as
+// Japanese, Chinese or Korean
+func PluralRuleOther(n float64) PluralCategory {
+	return PluralOther
+}
+
+// PluralRuleFrench is the rule for French and Portuguese (Brazil) where
+// 0 and 1 (including fractions below 2) are "one"
+func PluralRuleFrench(n float64) PluralCategory {
+	if n >= 0 && n < 2 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+// PluralRuleEastSlavic is the rule for Russian and Ukrainian integers,
+// fractions are "other"
+func PluralRuleEastSlavic(n float64) PluralCategory {
+	if n != math.Trunc(n) {
+		return PluralOther
+	}
+	i := int64(math.Abs(n))
+	switch {
+	case i%10 == 1 && i%100 != 11:
+		return PluralOne
+	case i%10 >= 2 && i%10 <= 4 && (i%100 < 12 || i%100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+// Message holds the plural forms of one catalog entry, a message that
+// doesn't vary by count just sets PluralOther.  Messages that take a count
+// use a "%d" (or "%s" for an already formatted value) where it goes.
+type Message map[PluralCategory]string
+
+// Locale is a message catalog plus the number formatting conventions for a
+// language, it's used by HumanDuration(), HumanSize(), BytesSize() and the
+// DurationFormatter/SizeFormatter types.  Any message missing from a
+// catalog falls back to the English one.
+type Locale struct {
+	Tag              string // BCP 47 style tag, eg: "en", "de", "pt-BR"
+	Plural           PluralRule
+	DecimalSeparator string
+	GroupSeparator   string
+	Messages         map[string]Message
+}
+
+var (
+	localeMu      sync.RWMutex
+	locales       = map[string]*Locale{}
+	currentLocale *Locale
+)
+
+func init() {
+	RegisterLocale(localeEnglish)
+	RegisterLocale(localeGerman)
+	RegisterLocale(localeJapanese)
+	currentLocale = localeEnglish
+}
+
+// normalizeTag turns things like "de_DE.UTF-8" or "DE-de" into "de-de"
+func normalizeTag(tag string) string {
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(strings.Replace(tag, "_", "-", -1))
+}
+
+// RegisterLocale adds (or replaces) a locale in the registry, this is how
+// applications add their own languages or override the bundled ones
+func RegisterLocale(l *Locale) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	locales[normalizeTag(l.Tag)] = l
+}
+
+// LookupLocale finds a registered locale, "de_DE.UTF-8" or "de-AT" will
+// fall back to "de" if there's no more specific entry, nil if not found
+func LookupLocale(tag string) *Locale {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	tag = normalizeTag(tag)
+	for tag != "" {
+		if l, ok := locales[tag]; ok {
+			return l
+		}
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return nil
+}
+
+// SetLocale picks the locale used by the package level functions and by
+// formatters that don't name their own locale
+func SetLocale(tag string) error {
+	l := LookupLocale(tag)
+	if l == nil {
+		return fmt.Errorf("unknown locale '%s'", tag)
+	}
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	currentLocale = l
+	return nil
+}
+
+// SetLocaleFromEnv sets the locale from $LC_ALL, $LC_MESSAGES or $LANG
+// (the first that is set), leaving it alone if none of those are set or
+// known.  It returns the tag of the locale in use afterwards.
+func SetLocaleFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if val := os.Getenv(name); val != "" {
+			if val != "C" && val != "POSIX" {
+				SetLocale(val)
+			}
+			break
+		}
+	}
+	return CurrentLocale().Tag
+}
+
+// CurrentLocale returns the locale set via SetLocale(), English by default
+func CurrentLocale() *Locale {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	return currentLocale
+}
+
+// localeOrCurrent looks up the given tag, using the current locale if the
+// tag is empty or unknown
+func localeOrCurrent(tag string) *Locale {
+	if tag != "" {
+		if l := LookupLocale(tag); l != nil {
+			return l
+		}
+	}
+	return CurrentLocale()
+}
+
+// plural returns the plural category for n in this locale
+func (l *Locale) plural(n float64) PluralCategory {
+	if l.Plural == nil {
+		return PluralOther
+	}
+	return l.Plural(n)
+}
+
+// lookup finds the message text for a key and plural category, falling
+// back to the "other" form and then to the English catalog
+func (l *Locale) lookup(key string, cat PluralCategory) string {
+	if msg, ok := l.Messages[key]; ok {
+		if text, ok := msg[cat]; ok {
+			return text
+		}
+		if text, ok := msg[PluralOther]; ok {
+			return text
+		}
+	}
+	if l != localeEnglish {
+		// the english catalog only has "one" and "other" forms
+		if cat != PluralOne {
+			cat = PluralOther
+		}
+		return localeEnglish.lookup(key, cat)
+	}
+	return key
+}
+
+// has checks if this locales own catalog (not the fallback) has a message
+func (l *Locale) has(key string) bool {
+	_, ok := l.Messages[key]
+	return ok
+}
+
+// Text returns a message that doesn't take a count
+func (l *Locale) Text(key string) string {
+	return l.lookup(key, PluralOther)
+}
+
+// Count returns a message in the plural form for n with n filled in
+func (l *Locale) Count(key string, n int64) string {
+	text := l.lookup(key, l.plural(float64(n)))
+	if strings.Contains(text, "%") {
+		return fmt.Sprintf(text, n)
+	}
+	return text
+}
+
+// CountString is like Count() but for an already formatted number (ie:
+// "1,5"), n is only used to pick the plural form
+func (l *Locale) CountString(key string, n float64, num string) string {
+	text := l.lookup(key, l.plural(n))
+	if strings.Contains(text, "%") {
+		return fmt.Sprintf(text, num)
+	}
+	return text
+}
+
+// Sprintf formats a message (that doesn't depend on a count) with args
+func (l *Locale) Sprintf(key string, args ...interface{}) string {
+	return fmt.Sprintf(l.Text(key), args...)
+}
+
+// decimal replaces the "." in a formatted number with the locales decimal
+// separator
+func (l *Locale) decimal(num string) string {
+	if l.DecimalSeparator == "" || l.DecimalSeparator == "." {
+		return num
+	}
+	return strings.Replace(num, ".", l.DecimalSeparator, 1)
+}
@@ -0,0 +1,76 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+// localeGerman is the bundled German catalog
+var localeGerman = &Locale{
+	Tag:              "de",
+	Plural:           PluralRuleOneOther,
+	DecimalSeparator: ",",
+	GroupSeparator:   ".",
+	Messages: map[string]Message{
+		"duration.less-than-second": {PluralOther: "Weniger als eine Sekunde"},
+		"duration.seconds":          {PluralOne: "%d Sekunde", PluralOther: "%d Sekunden"},
+		"duration.about-minute":     {PluralOther: "Etwa eine Minute"},
+		"duration.minutes":          {PluralOne: "%d Minute", PluralOther: "%d Minuten"},
+		"duration.about-hour":       {PluralOther: "Etwa eine Stunde"},
+		"duration.hours":            {PluralOne: "%d Stunde", PluralOther: "%d Stunden"},
+		"duration.days":             {PluralOne: "%d Tag", PluralOther: "%d Tage"},
+		"duration.weeks":            {PluralOne: "%d Woche", PluralOther: "%d Wochen"},
+		"duration.months":           {PluralOne: "%d Monat", PluralOther: "%d Monate"},
+		"duration.years":            {PluralOne: "%d Jahr", PluralOther: "%d Jahre"},
+
+		"unit.year":        {PluralOne: "%d Jahr", PluralOther: "%d Jahre"},
+		"unit.month":       {PluralOne: "%d Monat", PluralOther: "%d Monate"},
+		"unit.week":        {PluralOne: "%d Woche", PluralOther: "%d Wochen"},
+		"unit.day":         {PluralOne: "%d Tag", PluralOther: "%d Tage"},
+		"unit.hour":        {PluralOne: "%d Stunde", PluralOther: "%d Stunden"},
+		"unit.minute":      {PluralOne: "%d Minute", PluralOther: "%d Minuten"},
+		"unit.second":      {PluralOne: "%d Sekunde", PluralOther: "%d Sekunden"},
+		"unit.millisecond": {PluralOne: "%d Millisekunde", PluralOther: "%d Millisekunden"},
+		"unit.microsecond": {PluralOne: "%d Mikrosekunde", PluralOther: "%d Mikrosekunden"},
+		"unit.nanosecond":  {PluralOne: "%d Nanosekunde", PluralOther: "%d Nanosekunden"},
+
+		// "vor 3 Tagen" / "in 3 Tagen" use the dative plural
+		"relative.past":       {PluralOther: "vor %s"},
+		"relative.future":     {PluralOther: "in %s"},
+		"relative.now":        {PluralOther: "gerade eben"},
+		"relative.unit.year":  {PluralOne: "%d Jahr", PluralOther: "%d Jahren"},
+		"relative.unit.month": {PluralOne: "%d Monat", PluralOther: "%d Monaten"},
+		"relative.unit.day":   {PluralOne: "%d Tag", PluralOther: "%d Tagen"},
+
+		"progress.left":     {PluralOther: "%s, noch %s"},
+		"progress.finished": {PluralOther: "%s, fertig in %s"},
+
+		"size.byte":      {PluralOther: "%s Byte"},
+		"size.kilobyte":  {PluralOther: "%s Kilobyte"},
+		"size.megabyte":  {PluralOther: "%s Megabyte"},
+		"size.gigabyte":  {PluralOther: "%s Gigabyte"},
+		"size.terabyte":  {PluralOther: "%s Terabyte"},
+		"size.petabyte":  {PluralOther: "%s Petabyte"},
+		"size.exabyte":   {PluralOther: "%s Exabyte"},
+		"size.zettabyte": {PluralOther: "%s Zettabyte"},
+		"size.yottabyte": {PluralOther: "%s Yottabyte"},
+		"size.kibibyte":  {PluralOther: "%s Kibibyte"},
+		"size.mebibyte":  {PluralOther: "%s Mebibyte"},
+		"size.gibibyte":  {PluralOther: "%s Gibibyte"},
+		"size.tebibyte":  {PluralOther: "%s Tebibyte"},
+		"size.pebibyte":  {PluralOther: "%s Pebibyte"},
+		"size.exbibyte":  {PluralOther: "%s Exbibyte"},
+		"size.zebibyte":  {PluralOther: "%s Zebibyte"},
+		"size.yobibyte":  {PluralOther: "%s Yobibyte"},
+	},
+}
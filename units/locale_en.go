@@ -0,0 +1,102 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+// localeEnglish is the default locale and the fallback for any message
+// missing from another locales catalog
+var localeEnglish = &Locale{
+	Tag:              "en",
+	Plural:           PluralRuleOneOther,
+	DecimalSeparator: ".",
+	GroupSeparator:   ",",
+	Messages: map[string]Message{
+		// HumanDuration()
+		"duration.less-than-second": {PluralOther: "Less than a second"},
+		"duration.seconds":          {PluralOne: "%d second", PluralOther: "%d seconds"},
+		"duration.about-minute":     {PluralOther: "About a minute"},
+		"duration.minutes":          {PluralOne: "%d minute", PluralOther: "%d minutes"},
+		"duration.about-hour":       {PluralOther: "About an hour"},
+		"duration.hours":            {PluralOne: "%d hour", PluralOther: "%d hours"},
+		"duration.days":             {PluralOne: "%d day", PluralOther: "%d days"},
+		"duration.weeks":            {PluralOne: "%d week", PluralOther: "%d weeks"},
+		"duration.months":           {PluralOne: "%d month", PluralOther: "%d months"},
+		"duration.years":            {PluralOne: "%d year", PluralOther: "%d years"},
+
+		// DurationFormatter long and short unit names
+		"unit.year":              {PluralOne: "%d year", PluralOther: "%d years"},
+		"unit.month":             {PluralOne: "%d month", PluralOther: "%d months"},
+		"unit.week":              {PluralOne: "%d week", PluralOther: "%d weeks"},
+		"unit.day":               {PluralOne: "%d day", PluralOther: "%d days"},
+		"unit.hour":              {PluralOne: "%d hour", PluralOther: "%d hours"},
+		"unit.minute":            {PluralOne: "%d minute", PluralOther: "%d minutes"},
+		"unit.second":            {PluralOne: "%d second", PluralOther: "%d seconds"},
+		"unit.millisecond":       {PluralOne: "%d millisecond", PluralOther: "%d milliseconds"},
+		"unit.microsecond":       {PluralOne: "%d microsecond", PluralOther: "%d microseconds"},
+		"unit.nanosecond":        {PluralOne: "%d nanosecond", PluralOther: "%d nanoseconds"},
+		"unit.year.short":        {PluralOther: "%dy"},
+		"unit.month.short":       {PluralOther: "%dmo"},
+		"unit.week.short":        {PluralOther: "%dw"},
+		"unit.day.short":         {PluralOther: "%dd"},
+		"unit.hour.short":        {PluralOther: "%dh"},
+		"unit.minute.short":      {PluralOther: "%dm"},
+		"unit.second.short":      {PluralOther: "%ds"},
+		"unit.millisecond.short": {PluralOther: "%dms"},
+		"unit.microsecond.short": {PluralOther: "%dus"},
+		"unit.nanosecond.short":  {PluralOther: "%dns"},
+		"list.separator":         {PluralOther: ", "},
+
+		// DurationFormatter.Relative(), the relative.unit.* keys can be used
+		// by languages that need a different case after "ago" (eg: German)
+		"relative.past":   {PluralOther: "%s ago"},
+		"relative.future": {PluralOther: "in %s"},
+		"relative.now":    {PluralOther: "just now"},
+
+		// progress.Stats.String(), ie: "3 kB / 5 kB (60%), 1 kB/s, 2 seconds left"
+		"progress.total":    {PluralOther: "%s / %s (%s)"},
+		"progress.rate":     {PluralOther: "%s, %s"},
+		"progress.left":     {PluralOther: "%s, %s left"},
+		"progress.finished": {PluralOther: "%s, done in %s"},
+
+		// SizeFormatter long unit names
+		"size.byte":      {PluralOne: "%s byte", PluralOther: "%s bytes"},
+		"size.kilobyte":  {PluralOne: "%s kilobyte", PluralOther: "%s kilobytes"},
+		"size.megabyte":  {PluralOne: "%s megabyte", PluralOther: "%s megabytes"},
+		"size.gigabyte":  {PluralOne: "%s gigabyte", PluralOther: "%s gigabytes"},
+		"size.terabyte":  {PluralOne: "%s terabyte", PluralOther: "%s terabytes"},
+		"size.petabyte":  {PluralOne: "%s petabyte", PluralOther: "%s petabytes"},
+		"size.exabyte":   {PluralOne: "%s exabyte", PluralOther: "%s exabytes"},
+		"size.zettabyte": {PluralOne: "%s zettabyte", PluralOther: "%s zettabytes"},
+		"size.yottabyte": {PluralOne: "%s yottabyte", PluralOther: "%s yottabytes"},
+		"size.kibibyte":  {PluralOne: "%s kibibyte", PluralOther: "%s kibibytes"},
+		"size.mebibyte":  {PluralOne: "%s mebibyte", PluralOther: "%s mebibytes"},
+		"size.gibibyte":  {PluralOne: "%s gibibyte", PluralOther: "%s gibibytes"},
+		"size.tebibyte":  {PluralOne: "%s tebibyte", PluralOther: "%s tebibytes"},
+		"size.pebibyte":  {PluralOne: "%s pebibyte", PluralOther: "%s pebibytes"},
+		"size.exbibyte":  {PluralOne: "%s exbibyte", PluralOther: "%s exbibytes"},
+		"size.zebibyte":  {PluralOne: "%s zebibyte", PluralOther: "%s zebibytes"},
+		"size.yobibyte":  {PluralOne: "%s yobibyte", PluralOther: "%s yobibytes"},
+	},
+}
+
+func init() {
+	// unit abbreviations ("size.kB", "size.MiB", ...) are the same as the
+	// key in english, other languages can override them (eg: "ko" in French)
+	for _, abbrs := range [][]string{decimapAbbrs, binaryAbbrs, jedecAbbrs} {
+		for _, abbr := range abbrs {
+			localeEnglish.Messages["size."+abbr] = Message{PluralOther: abbr}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+// localeJapanese is the bundled Japanese catalog, Japanese has no plural
+// forms and puts no space between a number and its unit
+var localeJapanese = &Locale{
+	Tag:              "ja",
+	Plural:           PluralRuleOther,
+	DecimalSeparator: ".",
+	GroupSeparator:   ",",
+	Messages: map[string]Message{
+		"duration.less-than-second": {PluralOther: "1秒未満"},
+		"duration.seconds":          {PluralOther: "%d秒"},
+		"duration.about-minute":     {PluralOther: "約1分"},
+		"duration.minutes":          {PluralOther: "%d分"},
+		"duration.about-hour":       {PluralOther: "約1時間"},
+		"duration.hours":            {PluralOther: "%d時間"},
+		"duration.days":             {PluralOther: "%d日"},
+		"duration.weeks":            {PluralOther: "%d週間"},
+		"duration.months":           {PluralOther: "%dか月"},
+		"duration.years":            {PluralOther: "%d年"},
+
+		"unit.year":        {PluralOther: "%d年"},
+		"unit.month":       {PluralOther: "%dか月"},
+		"unit.week":        {PluralOther: "%d週間"},
+		"unit.day":         {PluralOther: "%d日"},
+		"unit.hour":        {PluralOther: "%d時間"},
+		"unit.minute":      {PluralOther: "%d分"},
+		"unit.second":      {PluralOther: "%d秒"},
+		"unit.millisecond": {PluralOther: "%dミリ秒"},
+		"unit.microsecond": {PluralOther: "%dマイクロ秒"},
+		"unit.nanosecond":  {PluralOther: "%dナノ秒"},
+		"list.separator":   {PluralOther: ""},
+
+		"relative.past":   {PluralOther: "%s前"},
+		"relative.future": {PluralOther: "%s後"},
+		"relative.now":    {PluralOther: "たった今"},
+
+		"progress.rate":     {PluralOther: "%s、%s"},
+		"progress.left":     {PluralOther: "%s、残り%s"},
+		"progress.finished": {PluralOther: "%s、%sで完了"},
+
+		"size.byte":      {PluralOther: "%sバイト"},
+		"size.kilobyte":  {PluralOther: "%sキロバイト"},
+		"size.megabyte":  {PluralOther: "%sメガバイト"},
+		"size.gigabyte":  {PluralOther: "%sギガバイト"},
+		"size.terabyte":  {PluralOther: "%sテラバイト"},
+		"size.petabyte":  {PluralOther: "%sペタバイト"},
+		"size.exabyte":   {PluralOther: "%sエクサバイト"},
+		"size.zettabyte": {PluralOther: "%sゼタバイト"},
+		"size.yottabyte": {PluralOther: "%sヨタバイト"},
+		"size.kibibyte":  {PluralOther: "%sキビバイト"},
+		"size.mebibyte":  {PluralOther: "%sメビバイト"},
+		"size.gibibyte":  {PluralOther: "%sギビバイト"},
+		"size.tebibyte":  {PluralOther: "%sテビバイト"},
+		"size.pebibyte":  {PluralOther: "%sペビバイト"},
+		"size.exbibyte":  {PluralOther: "%sエクスビバイト"},
+		"size.zebibyte":  {PluralOther: "%sゼビバイト"},
+		"size.yobibyte":  {PluralOther: "%sヨビバイト"},
+	},
+}
@@ -0,0 +1,122 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocaleHumanDuration(t *testing.T) {
+	defer SetLocale("en")
+	if err := SetLocale("de"); err != nil {
+		t.Fatalf("SetLocale(de) failed: %s", err)
+	}
+	assertEquals(t, "Weniger als eine Sekunde", HumanDuration(450*time.Millisecond))
+	assertEquals(t, "47 Sekunden", HumanDuration(47*time.Second))
+	assertEquals(t, "Etwa eine Minute", HumanDuration(1*time.Minute))
+	assertEquals(t, "3 Tage", HumanDuration(3*24*time.Hour))
+	assertEquals(t, "3 Jahre", HumanDuration(3*365*24*time.Hour))
+
+	if err := SetLocale("ja"); err != nil {
+		t.Fatalf("SetLocale(ja) failed: %s", err)
+	}
+	assertEquals(t, "47秒", HumanDuration(47*time.Second))
+}
+
+func TestLocaleSize(t *testing.T) {
+	defer SetLocale("en")
+	SetLocale("de")
+	assertEquals(t, "2,746 MB", HumanSize(2746000))
+	assertEquals(t, "1,5 KiB", BytesSize(1536))
+	assertEquals(t, "1,5 Kilobyte", SizeFormatter{Precision: 1, Style: UnitLong}.Format(1500))
+
+	// a formatter can pick its own locale
+	SetLocale("en")
+	assertEquals(t, "1,5 MB", SizeFormatter{Precision: 1, Locale: "de"}.Format(1500000))
+	assertEquals(t, "1.5 MB", SizeFormatter{Precision: 1}.Format(1500000))
+}
+
+func TestLocaleRelative(t *testing.T) {
+	now := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	de := DurationFormatter{Long: true, Now: clock, Locale: "de"}
+	assertEquals(t, "vor 3 Tagen", de.Relative(now.Add(-3*Day)))
+	assertEquals(t, "in 1 Tag", de.Relative(now.Add(Day)))
+	assertEquals(t, "vor 5 Minuten", de.Relative(now.Add(-5*time.Minute)))
+	assertEquals(t, "gerade eben", de.Relative(now))
+	assertEquals(t, "1 Stunde, 2 Minuten", DurationFormatter{Long: true, Locale: "de"}.Format(62*time.Minute))
+
+	ja := DurationFormatter{Long: true, Now: clock, Locale: "ja"}
+	assertEquals(t, "3分前", ja.Relative(now.Add(-3*time.Minute)))
+	assertEquals(t, "1時間2分", DurationFormatter{Long: true, Locale: "ja"}.Format(62*time.Minute))
+}
+
+func TestPluralRules(t *testing.T) {
+	assertEquals(t, PluralOne, PluralRuleOneOther(1))
+	assertEquals(t, PluralOther, PluralRuleOneOther(0))
+	assertEquals(t, PluralOther, PluralRuleOneOther(1.5))
+	assertEquals(t, PluralOther, PluralRuleOther(1))
+	assertEquals(t, PluralOne, PluralRuleFrench(0))
+	assertEquals(t, PluralOne, PluralRuleFrench(1.5))
+	assertEquals(t, PluralOther, PluralRuleFrench(2))
+	assertEquals(t, PluralOne, PluralRuleEastSlavic(21))
+	assertEquals(t, PluralMany, PluralRuleEastSlavic(11))
+	assertEquals(t, PluralFew, PluralRuleEastSlavic(3))
+	assertEquals(t, PluralMany, PluralRuleEastSlavic(14))
+	assertEquals(t, PluralFew, PluralRuleEastSlavic(22))
+	assertEquals(t, PluralMany, PluralRuleEastSlavic(5))
+	assertEquals(t, PluralOther, PluralRuleEastSlavic(1.5))
+}
+
+func TestRegisterLocale(t *testing.T) {
+	defer SetLocale("en")
+	RegisterLocale(&Locale{
+		Tag:    "ru",
+		Plural: PluralRuleEastSlavic,
+		Messages: map[string]Message{
+			"unit.day": {PluralOne: "%d день", PluralFew: "%d дня", PluralMany: "%d дней"},
+		},
+	})
+	if err := SetLocale("ru_RU.UTF-8"); err != nil {
+		t.Fatalf("SetLocale(ru_RU.UTF-8) failed: %s", err)
+	}
+	long := DurationFormatter{Long: true}
+	assertEquals(t, "1 день", long.Format(Day))
+	assertEquals(t, "3 дня", long.Format(3*Day))
+	assertEquals(t, "5 дней", long.Format(5*Day))
+	// anything missing from the catalog comes from the english one
+	assertEquals(t, "2 hours", long.Format(2*time.Hour))
+	assertEquals(t, "Less than a second", HumanDuration(0))
+}
+
+func TestLookupLocale(t *testing.T) {
+	defer SetLocale("en")
+	if l := LookupLocale("de_DE.UTF-8"); l == nil || l.Tag != "de" {
+		t.Fatalf("expected de_DE.UTF-8 to fall back to de, got %v", l)
+	}
+	if l := LookupLocale("DE-at"); l == nil || l.Tag != "de" {
+		t.Fatalf("expected DE-at to fall back to de, got %v", l)
+	}
+	if l := LookupLocale("xx"); l != nil {
+		t.Fatalf("expected no locale for xx, got %s", l.Tag)
+	}
+	if err := SetLocale("xx"); err == nil {
+		t.Fatalf("expected an error setting an unknown locale")
+	}
+	assertEquals(t, "en", CurrentLocale().Tag)
+}
@@ -0,0 +1,252 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// siPrefixes are the SI prefixes from yocto (1e-24) to yotta (1e24), the
+// empty prefix is at siUnity
+var siPrefixes = []string{"y", "z", "a", "f", "p", "n", "µ", "m", "", "k", "M", "G", "T", "P", "E", "Z", "Y"}
+
+const siUnity = 8
+
+// countSuffixes are the suffixes HumanCount() uses, powers of 1000
+var countSuffixes = []string{"", "k", "M", "G", "T", "P", "E"}
+
+// scaleNumber is the loop-and-divide core for the human formats, it scales
+// the value by base until its magnitude is in [1, base) (or it runs out of
+// units between lo and hi) and returns the scaled value with the unit index.
+// Unlike the old CustomSize() loop it handles negative values and, with a
+// negative lo, values below 1.
+func scaleNumber(val, base float64, lo, hi int) (float64, int) {
+	i := 0
+	if math.IsNaN(val) || math.IsInf(val, 0) {
+		return val, i
+	}
+	for math.Abs(val) >= base && i < hi {
+		val /= base
+		i++
+	}
+	for val != 0 && math.Abs(val) < 1 && i > lo {
+		val *= base
+		i--
+	}
+	return val, i
+}
+
+// trimDecimals formats the value with the given number of decimals and
+// drops any trailing zeros (ie: "1.50" -> "1.5", "2.0" -> "2")
+func trimDecimals(val float64, decimals int) string {
+	num := strconv.FormatFloat(val, 'f', decimals, 64)
+	if strings.Contains(num, ".") {
+		num = strings.TrimRight(strings.TrimRight(num, "0"), ".")
+	}
+	if num == "-0" {
+		num = "0"
+	}
+	return num
+}
+
+// HumanCount formats a count compactly with 1 decimal, ie: "999", "1.2k",
+// "3.4M" or "-12.3G", see GroupedCount() for the full number
+func HumanCount(n float64) string {
+	return CurrentLocale().HumanCount(n)
+}
+
+// HumanCount is HumanCount() for this locale
+func (l *Locale) HumanCount(n float64) string {
+	val, i := scaleNumber(n, 1000, 0, len(countSuffixes)-1)
+	// rounding can push us up to the next unit (999.96k -> 1000k)
+	if math.Abs(roundTo(val, 1)) >= 1000 && i < len(countSuffixes)-1 {
+		val /= 1000
+		i++
+	}
+	return l.decimal(trimDecimals(val, 1)) + countSuffixes[i]
+}
+
+// GroupedCount formats an integer with the locales digit grouping, ie:
+// "1,234,567" (or "1.234.567" for de)
+func GroupedCount(n int64) string {
+	return CurrentLocale().GroupedCount(n)
+}
+
+// GroupedCount is GroupedCount() for this locale
+func (l *Locale) GroupedCount(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if l.GroupSeparator == "" || len(digits) <= 3 {
+		return sign + digits
+	}
+	groups := make([]string, 0, len(digits)/3+1)
+	first := len(digits) % 3
+	if first > 0 {
+		groups = append(groups, digits[:first])
+	}
+	for i := first; i < len(digits); i += 3 {
+		groups = append(groups, digits[i:i+3])
+	}
+	return sign + strings.Join(groups, l.GroupSeparator)
+}
+
+// SI formats a value of some unit with an SI prefix and 4 significant
+// digits, ie: SI(0.0032, "s") is "3.2 ms" and SI(12500000, "Hz") is
+// "12.5 MHz"
+func SI(val float64, unit string) string {
+	return CurrentLocale().FormatSI(val, unit, -1)
+}
+
+// FormatSI formats a value with an SI prefix and the given number of
+// decimals (trailing zeros dropped), a negative decimals gives 4
+// significant digits like SI() does
+func FormatSI(val float64, unit string, decimals int) string {
+	return CurrentLocale().FormatSI(val, unit, decimals)
+}
+
+// FormatSI is FormatSI() for this locale
+func (l *Locale) FormatSI(val float64, unit string, decimals int) string {
+	scaled, i := scaleNumber(val, 1000, -siUnity, len(siPrefixes)-1-siUnity)
+	num := formatSignificant(scaled, decimals)
+	if rounded, _ := strconv.ParseFloat(num, 64); math.Abs(rounded) >= 1000 && i < len(siPrefixes)-1-siUnity {
+		i++
+		num = formatSignificant(scaled/1000, decimals)
+	}
+	return l.decimal(num) + " " + siPrefixes[i+siUnity] + unit
+}
+
+// formatSignificant formats with the given decimals, or 4 significant
+// digits if decimals is negative
+func formatSignificant(val float64, decimals int) string {
+	if decimals >= 0 {
+		return trimDecimals(val, decimals)
+	}
+	abs := math.Abs(val)
+	if abs < 1 {
+		// zero or past the smallest prefix
+		return strconv.FormatFloat(val, 'g', 4, 64)
+	}
+	decimals = 4 - len(strconv.FormatFloat(math.Trunc(abs), 'f', 0, 64))
+	if decimals < 0 {
+		decimals = 0
+	}
+	return trimDecimals(val, decimals)
+}
+
+// HumanPercent formats a ratio as a percentage with 1 decimal, ie: 0.125
+// is "12.5%" and 1 is "100%"
+func HumanPercent(ratio float64) string {
+	return CurrentLocale().decimal(trimDecimals(ratio*100, 1)) + "%"
+}
+
+// ParseCount parses the HumanCount() and GroupedCount() forms back into a
+// number, ie: "1.2k", "3.4M", "1,234,567" or plain "42".  The separators
+// are those of the current locale.
+func ParseCount(s string) (float64, error) {
+	l := CurrentLocale()
+	str := strings.TrimSpace(s)
+	mul := 1.0
+	for i := len(countSuffixes) - 1; i > 0; i-- {
+		suffix := countSuffixes[i]
+		if strings.HasSuffix(str, suffix) || strings.HasSuffix(str, strings.ToUpper(suffix)) {
+			str = strings.TrimSpace(str[:len(str)-len(suffix)])
+			mul = math.Pow(1000, float64(i))
+			break
+		}
+	}
+	val, err := l.parseNumber(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid count '%s': %s", s, err)
+	}
+	return val * mul, nil
+}
+
+// ParseSI parses the SI() form back into a number of the given unit, ie:
+// ParseSI("3.2 ms", "s") is 0.0032, the space is optional and "u" is
+// accepted for micro
+func ParseSI(s, unit string) (float64, error) {
+	str := strings.TrimSpace(s)
+	if !strings.HasSuffix(str, unit) {
+		return 0, fmt.Errorf("invalid value '%s': missing unit '%s'", s, unit)
+	}
+	str = strings.TrimSpace(strings.TrimSuffix(str, unit))
+	exp := 0
+	for i, prefix := range siPrefixes {
+		if prefix == "" {
+			continue
+		}
+		if strings.HasSuffix(str, prefix) || prefix == "µ" && (strings.HasSuffix(str, "u") || strings.HasSuffix(str, "μ")) {
+			_, size := lastRune(str)
+			str = strings.TrimSpace(str[:len(str)-size])
+			exp = 3 * (i - siUnity)
+			break
+		}
+	}
+	val, err := CurrentLocale().parseNumber(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s': %s", s, err)
+	}
+	return val * math.Pow(10, float64(exp)), nil
+}
+
+// ParsePercent parses the HumanPercent() form back into a ratio, ie:
+// "12.5%" is 0.125
+func ParsePercent(s string) (float64, error) {
+	str := strings.TrimSpace(s)
+	if !strings.HasSuffix(str, "%") {
+		return 0, fmt.Errorf("invalid percentage '%s': missing '%%'", s)
+	}
+	val, err := CurrentLocale().parseNumber(strings.TrimSpace(strings.TrimSuffix(str, "%")))
+	if err != nil {
+		return 0, fmt.Errorf("invalid percentage '%s': %s", s, err)
+	}
+	return val / 100, nil
+}
+
+// lastRune returns the last rune of the string and its size in bytes
+func lastRune(s string) (rune, int) {
+	r := []rune(s)
+	if len(r) == 0 {
+		return 0, 0
+	}
+	last := r[len(r)-1]
+	return last, len(string(last))
+}
+
+// parseNumber parses a number using the locales group and decimal
+// separators
+func (l *Locale) parseNumber(str string) (float64, error) {
+	if str == "" {
+		return 0, fmt.Errorf("missing number")
+	}
+	if l.GroupSeparator != "" {
+		str = strings.Replace(str, l.GroupSeparator, "", -1)
+	}
+	if l.DecimalSeparator != "" && l.DecimalSeparator != "." {
+		str = strings.Replace(str, l.DecimalSeparator, ".", 1)
+	}
+	val, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+		return 0, fmt.Errorf("bad number '%s'", str)
+	}
+	return val, nil
+}
@@ -0,0 +1,134 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHumanCount(t *testing.T) {
+	assertEquals(t, "0", HumanCount(0))
+	assertEquals(t, "999", HumanCount(999))
+	assertEquals(t, "1k", HumanCount(1000))
+	assertEquals(t, "1.2k", HumanCount(1234))
+	assertEquals(t, "123.5k", HumanCount(123456))
+	assertEquals(t, "1M", HumanCount(999960))
+	assertEquals(t, "3.4M", HumanCount(3400000))
+	assertEquals(t, "-12.3G", HumanCount(-12.3e9))
+	assertEquals(t, "12.5", HumanCount(12.5))
+
+	assertEquals(t, "1,234,567", GroupedCount(1234567))
+	assertEquals(t, "-123,456", GroupedCount(-123456))
+	assertEquals(t, "999", GroupedCount(999))
+	assertEquals(t, "-9,223,372,036,854,775,808", GroupedCount(math.MinInt64))
+
+	defer SetLocale("en")
+	SetLocale("de")
+	assertEquals(t, "1.234.567", GroupedCount(1234567))
+	assertEquals(t, "1,2k", HumanCount(1234))
+}
+
+func TestSI(t *testing.T) {
+	assertEquals(t, "3.2 ms", SI(0.0032, "s"))
+	assertEquals(t, "12.5 MHz", SI(12500000, "Hz"))
+	assertEquals(t, "1.235 kV", SI(1234.6, "V"))
+	assertEquals(t, "250 µs", SI(0.00025, "s"))
+	assertEquals(t, "-1.5 mA", SI(-0.0015, "A"))
+	assertEquals(t, "1 s", SI(0.9999999, "s"))
+	assertEquals(t, "1 s", SI(1, "s"))
+	assertEquals(t, "0 s", SI(0, "s"))
+	assertEquals(t, "3.2 m", FormatSI(3.21, "m", 1))
+	assertEquals(t, "3 km", FormatSI(2999.7, "m", 0))
+	assertEquals(t, "-1.5 kB", HumanSize(-1500))
+}
+
+func TestHumanPercent(t *testing.T) {
+	assertEquals(t, "12.5%", HumanPercent(0.125))
+	assertEquals(t, "100%", HumanPercent(1))
+	assertEquals(t, "33.3%", HumanPercent(1.0/3))
+}
+
+func TestParseNumbers(t *testing.T) {
+	for in, expected := range map[string]float64{
+		"42":        42,
+		"1.2k":      1200,
+		"1.2K":      1200,
+		"3.4M":      3.4e6,
+		"-2G":       -2e9,
+		"1,234,567": 1234567,
+		" 5 k ":     5000,
+	} {
+		val, err := ParseCount(in)
+		if err != nil {
+			t.Fatalf("ParseCount(%q) failed: %s", in, err)
+		}
+		if math.Abs(val-expected) > 1e-6 {
+			t.Fatalf("ParseCount(%q): expected %v, got %v", in, expected, val)
+		}
+	}
+	for _, in := range []string{"", "k", "1.2x", "abc"} {
+		if _, err := ParseCount(in); err == nil {
+			t.Fatalf("expected ParseCount(%q) to fail", in)
+		}
+	}
+
+	for in, expected := range map[string]float64{
+		"3.2 ms":  0.0032,
+		"3.2ms":   0.0032,
+		"250 µs":  0.00025,
+		"250us":   0.00025,
+		"12.5MHz": 12.5e6,
+		"7 s":     7,
+	} {
+		unit := "s"
+		if in == "12.5MHz" {
+			unit = "Hz"
+		}
+		val, err := ParseSI(in, unit)
+		if err != nil {
+			t.Fatalf("ParseSI(%q) failed: %s", in, err)
+		}
+		if math.Abs(val-expected) > 1e-12*math.Max(1, expected) {
+			t.Fatalf("ParseSI(%q): expected %v, got %v", in, expected, val)
+		}
+	}
+	if _, err := ParseSI("3.2 ms", "Hz"); err == nil {
+		t.Fatalf("expected ParseSI to fail on the wrong unit")
+	}
+	if _, err := ParseSI("x ms", "s"); err == nil {
+		t.Fatalf("expected ParseSI to fail on a bad number")
+	}
+
+	ratio, err := ParsePercent("12.5%")
+	if err != nil || ratio != 0.125 {
+		t.Fatalf("ParsePercent failed: %v, %v", ratio, err)
+	}
+	if _, err := ParsePercent("12.5"); err == nil {
+		t.Fatalf("expected ParsePercent to fail without a '%%'")
+	}
+
+	defer SetLocale("en")
+	SetLocale("de")
+	val, err := ParseCount("1.234.567")
+	if err != nil || val != 1234567 {
+		t.Fatalf("ParseCount with de grouping failed: %v, %v", val, err)
+	}
+	val, err = ParseCount("1,5k")
+	if err != nil || val != 1500 {
+		t.Fatalf("ParseCount with de decimals failed: %v, %v", val, err)
+	}
+}
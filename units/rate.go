@@ -0,0 +1,331 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate is a transfer rate in bytes per second
+type Rate float64
+
+var bitRateAbbrs = []string{"bit/s", "kbit/s", "Mbit/s", "Gbit/s", "Tbit/s", "Pbit/s", "Ebit/s", "Zbit/s", "Ybit/s"}
+
+// rateSuffixes are the "per second" suffixes ParseRate() accepts, longest
+// first so "/sec" isn't mistaken for "/s"
+var rateSuffixes = []string{"/sec", "/s", "ps"}
+
+// BitRate returns the Rate for the given number of bits per second
+func BitRate(bitsPerSec float64) Rate {
+	return Rate(bitsPerSec / 8)
+}
+
+// BytesPerSec returns the rate as a plain number of bytes per second
+func (r Rate) BytesPerSec() float64 {
+	return float64(r)
+}
+
+// BitsPerSec returns the rate as a number of bits per second
+func (r Rate) BitsPerSec() float64 {
+	return float64(r) * 8
+}
+
+// String formats the rate in decimal bytes per second (ie: "12.3 MB/s")
+func (r Rate) String() string {
+	return HumanSize(float64(r)) + "/s"
+}
+
+// Bits formats the rate in decimal bits per second, the way network speeds
+// are usually given (ie: "98 Mbit/s")
+func (r Rate) Bits() string {
+	return CurrentLocale().decimal(CustomSize("%.4g %s", r.BitsPerSec(), 1000.0, bitRateAbbrs))
+}
+
+// Set parses the given string into the rate, for flag.Value
+func (r *Rate) Set(value string) error {
+	rate, err := ParseRate(value)
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
+
+// Type returns the type name for pflag's flag usage output
+func (r *Rate) Type() string {
+	return "rate"
+}
+
+// MarshalText writes the exact rate as plain bytes per second (ie:
+// "12345678B/s"), String() rounds and uses the locales decimal separator
+// so it can't be read back reliably
+func (r Rate) MarshalText() ([]byte, error) {
+	f := float64(r)
+	if math.IsNaN(f) || math.IsInf(f, 0) || f < 0 {
+		return nil, fmt.Errorf("invalid rate %v: must be a finite, non-negative number", f)
+	}
+	return []byte(strconv.FormatFloat(f, 'f', -1, 64) + "B/s"), nil
+}
+
+// UnmarshalText parses a rate string, see ParseRate()
+func (r *Rate) UnmarshalText(text []byte) error {
+	return r.Set(string(text))
+}
+
+// ParseRate parses a transfer rate such as "10MB/s", "1.5 MiB/s", "500kB/sec"
+// or "10MBps" (bytes) and "100Mbps", "100 Mbit/s" or "1Gb/s" (bits).  An
+// upper case "B" means bytes, a lower case "b" or "bit" means bits, and the
+// number and unit prefix follow the ParseSize() rules (k, M, G are powers of
+// 1000, Ki, Mi, Gi powers of 1024).
+func ParseRate(s string) (Rate, error) {
+	str := strings.TrimSpace(s)
+	found := false
+	for _, suffix := range rateSuffixes {
+		if strings.HasSuffix(strings.ToLower(str), suffix) {
+			str = strings.TrimSpace(str[:len(str)-len(suffix)])
+			found = true
+			break
+		}
+	}
+	if !found {
+		return 0, rateErr(s, "missing '/s' or 'ps' suffix")
+	}
+
+	bits := false
+	lower := strings.ToLower(str)
+	switch {
+	case strings.HasSuffix(lower, "bits"):
+		str, bits = str[:len(str)-4], true
+	case strings.HasSuffix(lower, "bit"):
+		str, bits = str[:len(str)-3], true
+	case strings.HasSuffix(str, "b"):
+		str, bits = str[:len(str)-1], true
+	case strings.HasSuffix(str, "B"):
+		str = str[:len(str)-1]
+	default:
+		return 0, rateErr(s, "missing 'B' (bytes) or 'b'/'bit' (bits) unit")
+	}
+	if str == "" {
+		return 0, rateErr(s, "missing number")
+	}
+	if plain := strings.TrimSpace(str); strings.Trim(plain, "0123456789.") == "" {
+		// no unit prefix, keep any fraction rather than truncating it
+		if val, err := strconv.ParseFloat(plain, 64); err == nil {
+			if bits {
+				return BitRate(val), nil
+			}
+			return Rate(val), nil
+		}
+	}
+
+	val, err := ParseSize(str, ParseOptions{Strict: true})
+	if err != nil {
+		var sizeErr *SizeError
+		if errors.As(err, &sizeErr) {
+			err = sizeErr.Err
+		}
+		return 0, rateErr(s, err.Error())
+	}
+	if bits {
+		return BitRate(float64(val)), nil
+	}
+	return Rate(val), nil
+}
+
+// rateErr builds the error returned for a bad rate string
+func rateErr(s, why string) error {
+	return fmt.Errorf("invalid rate '%s': %s", s, why)
+}
+
+// RateMeter measures the rate of a transfer (download, copy, ...) from the
+// byte counts fed to it, it reports the instant rate (over the last sample
+// interval), an exponentially smoothed rate and the average rate since the
+// start, along with an ETA if the total size is known.  It's safe for use
+// from multiple goroutines.  Create one with NewRateMeter().
+type RateMeter struct {
+	Total    int64            // expected total bytes, 0 if unknown
+	Alpha    float64          // smoothing factor (0 < Alpha <= 1), larger follows the instant rate closer
+	Interval time.Duration    // minimum time between rate samples
+	Now      func() time.Time // clock, time.Now if nil
+
+	mu          sync.Mutex
+	started     bool
+	start       time.Time
+	sampleAt    time.Time
+	sampleN     int64
+	count       int64
+	instant     float64
+	smoothed    float64
+	hasSmoothed bool
+}
+
+// NewRateMeter returns a meter for a transfer of total bytes (0 if not
+// known) that samples every 500ms with a smoothing factor of 0.3, the
+// clock starts with the first call to Add() or Set() (or Start())
+func NewRateMeter(total int64) *RateMeter {
+	return &RateMeter{Total: total, Alpha: 0.3, Interval: 500 * time.Millisecond}
+}
+
+// now returns the current time from the meters clock
+func (m *RateMeter) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
+
+// Start (re)starts the clock, it's only needed if the time before the
+// first bytes arrive should count against the rate
+func (m *RateMeter) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.startAt(m.now())
+}
+
+// startAt resets the meter to start at the given time, m.mu must be held
+func (m *RateMeter) startAt(now time.Time) {
+	m.started = true
+	m.start, m.sampleAt = now, now
+	m.count, m.sampleN = 0, 0
+	m.instant, m.smoothed, m.hasSmoothed = 0, 0, false
+}
+
+// Add records n more bytes transferred
+func (m *RateMeter) Add(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.update(m.count + n)
+}
+
+// Set records the total number of bytes transferred so far
+func (m *RateMeter) Set(count int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.update(count)
+}
+
+// update records the new count and takes a rate sample if the interval
+// has passed, m.mu must be held
+func (m *RateMeter) update(count int64) {
+	now := m.now()
+	if !m.started {
+		m.startAt(now)
+	}
+	m.count = count
+	elapsed := now.Sub(m.sampleAt)
+	if elapsed <= 0 || elapsed < m.Interval {
+		return
+	}
+	m.instant = float64(m.count-m.sampleN) / elapsed.Seconds()
+	alpha := m.Alpha
+	if alpha <= 0 || alpha > 1 {
+		alpha = 1
+	}
+	if m.hasSmoothed {
+		m.smoothed = alpha*m.instant + (1-alpha)*m.smoothed
+	} else {
+		m.smoothed, m.hasSmoothed = m.instant, true
+	}
+	m.sampleAt, m.sampleN = now, m.count
+}
+
+// Count returns the number of bytes transferred so far
+func (m *RateMeter) Count() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.count
+}
+
+// Instant returns the rate over the last sample interval
+func (m *RateMeter) Instant() Rate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Rate(m.instant)
+}
+
+// Smoothed returns the exponentially smoothed rate, until the first
+// sample is taken this is the average rate
+func (m *RateMeter) Smoothed() Rate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.smoothedRate()
+}
+
+// smoothedRate is Smoothed() with m.mu held
+func (m *RateMeter) smoothedRate() Rate {
+	if !m.hasSmoothed {
+		return m.averageRate()
+	}
+	return Rate(m.smoothed)
+}
+
+// Average returns the average rate since the start
+func (m *RateMeter) Average() Rate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.averageRate()
+}
+
+// averageRate is Average() with m.mu held
+func (m *RateMeter) averageRate() Rate {
+	if !m.started {
+		return 0
+	}
+	elapsed := m.now().Sub(m.start)
+	if elapsed <= 0 {
+		return 0
+	}
+	return Rate(float64(m.count) / elapsed.Seconds())
+}
+
+// Remaining estimates the time left from the smoothed rate, ok is false
+// if the total isn't known or nothing has been transferred yet
+func (m *RateMeter) Remaining() (d time.Duration, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Total <= 0 {
+		return 0, false
+	}
+	left := m.Total - m.count
+	if left <= 0 {
+		return 0, true
+	}
+	rate := float64(m.smoothedRate())
+	if rate <= 0 {
+		return 0, false
+	}
+	secs := float64(left) / rate
+	if secs > float64(1<<63-1)/float64(time.Second) {
+		return 0, false
+	}
+	return time.Duration(secs * float64(time.Second)), true
+}
+
+// ETA returns the estimated time left formatted with HumanDuration() (ie:
+// "3 minutes"), or "" if it can't be estimated yet
+func (m *RateMeter) ETA() string {
+	d, ok := m.Remaining()
+	if !ok {
+		return ""
+	}
+	return HumanDuration(d)
+}
@@ -0,0 +1,127 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateString(t *testing.T) {
+	assertEquals(t, "12.3 MB/s", Rate(12300000).String())
+	assertEquals(t, "512 B/s", Rate(512).String())
+	assertEquals(t, "98 Mbit/s", Rate(12250000).Bits())
+	assertEquals(t, "1 Gbit/s", BitRate(1e9).Bits())
+	assertEquals(t, "800 bit/s", Rate(100).Bits())
+	assertEquals(t, float64(8000), Rate(1000).BitsPerSec())
+}
+
+func TestParseRate(t *testing.T) {
+	for in, expected := range map[string]Rate{
+		"10MB/s":     10 * MB,
+		"10 MB/s":    10 * MB,
+		"10MBps":     10 * MB,
+		"1.5 MiB/s":  1.5 * MiB,
+		"500kB/sec":  500 * KB,
+		"100Mbps":    100 * MB / 8,
+		"100 Mbit/s": 100 * MB / 8,
+		"1Gb/s":      GB / 8,
+		"64 kbits/s": 8 * KB,
+		"1 Mibit/s":  MiB / 8,
+		"512 B/s":    512,
+		" 2 GB/s ":   2 * GB,
+	} {
+		rate, err := ParseRate(in)
+		if err != nil {
+			t.Fatalf("ParseRate(%q) failed: %s", in, err)
+		}
+		assertEquals(t, expected, rate)
+	}
+	for _, in := range []string{"", "10MB", "10 xB/s", "MB/s", "b/s", "10M/s", "-1MB/s"} {
+		if _, err := ParseRate(in); err == nil {
+			t.Fatalf("expected ParseRate(%q) to fail", in)
+		}
+	}
+
+	var r Rate
+	if err := r.Set("100Mbps"); err != nil {
+		t.Fatalf("Set failed: %s", err)
+	}
+	assertEquals(t, "12.5 MB/s", r.String())
+	if err := r.UnmarshalText([]byte("1 kB/s")); err != nil {
+		t.Fatalf("UnmarshalText failed: %s", err)
+	}
+	assertEquals(t, Rate(1000), r)
+
+	// text round trips exactly and doesn't depend on the locale
+	defer SetLocale(CurrentLocale().Tag)
+	SetLocale("de")
+	for _, rate := range []Rate{0, 12345678, 1234.5625, BitRate(100 * MB)} {
+		text, err := rate.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%v) failed: %s", float64(rate), err)
+		}
+		var back Rate
+		if err := back.UnmarshalText(text); err != nil || back != rate {
+			t.Fatalf("MarshalText(%v) -> %s read back as %v (err: %v)", float64(rate), text, float64(back), err)
+		}
+	}
+	text, _ := Rate(12345678).MarshalText()
+	assertEquals(t, "12345678B/s", string(text))
+	if _, err := Rate(-1).MarshalText(); err == nil {
+		t.Fatalf("expected MarshalText to fail for a negative rate")
+	}
+}
+
+func TestRateMeter(t *testing.T) {
+	now := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := NewRateMeter(10 * MB)
+	m.Now = func() time.Time { return now }
+
+	if _, ok := m.Remaining(); ok {
+		t.Fatalf("expected no ETA before any bytes arrive")
+	}
+	assertEquals(t, "", m.ETA())
+
+	m.Add(0)
+	now = now.Add(time.Second)
+	m.Add(MB)
+	assertEquals(t, Rate(MB), m.Instant())
+	assertEquals(t, Rate(MB), m.Smoothed())
+	assertEquals(t, Rate(MB), m.Average())
+
+	// too soon for a new sample, the count still moves on
+	now = now.Add(100 * time.Millisecond)
+	m.Add(100 * KB)
+	assertEquals(t, Rate(MB), m.Instant())
+	assertEquals(t, int64(MB+100*KB), m.Count())
+
+	now = now.Add(900 * time.Millisecond)
+	m.Set(4 * MB)
+	assertEquals(t, Rate(3*MB), m.Instant())
+	assertEquals(t, Rate(0.3*3*MB+0.7*MB), m.Smoothed())
+	assertEquals(t, Rate(2*MB), m.Average())
+
+	d, ok := m.Remaining()
+	if !ok {
+		t.Fatalf("expected an ETA")
+	}
+	assertEquals(t, 3750*time.Millisecond, d.Round(time.Millisecond))
+	assertEquals(t, "3 seconds", m.ETA())
+
+	m.Set(10 * MB)
+	assertEquals(t, "Less than a second", m.ETA())
+}
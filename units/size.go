@@ -1,9 +1,10 @@
 package units
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
 	"regexp"
-	"strconv"
 	"strings"
 )
 
@@ -16,6 +17,7 @@ const (
 	GB = 1000 * MB
 	TB = 1000 * GB
 	PB = 1000 * TB
+	EB = 1000 * PB
 
 	// Binary
 
@@ -24,46 +26,105 @@ const (
 	GiB = 1024 * MiB
 	TiB = 1024 * GiB
 	PiB = 1024 * TiB
+	EiB = 1024 * PiB
 )
 
-type unitMap map[string]int64
-
 var (
-	decimalMap = unitMap{"k": KB, "m": MB, "g": GB, "t": TB, "p": PB}
-	binaryMap  = unitMap{"k": KiB, "m": MiB, "g": GiB, "t": TiB, "p": PiB}
-	sizeRegex  = regexp.MustCompile(`^(\d+)([kKmMgGtTpP])?[bB]?$`)
+	// number, optional space, optional unit prefix, optional "i" (IEC) and
+	// optional "b"/"B", the space and decimal checks are done separately
+	sizeRegex = regexp.MustCompile(`^(\d+(?:\.\d*)?|\.\d+)(\s*)([kKmMgGtTpPeEzZyY]?)([iI]?)([bB]?)$`)
+	// unit prefixes, index+1 is the power of the base they represent
+	sizePrefixes = "kmgtpezy"
 )
 
 var decimapAbbrs = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB", "ZB", "YB"}
 var binaryAbbrs = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB", "ZiB", "YiB"}
 
+// Errors that ParseSize can return (wrapped in a *SizeError)
+var (
+	ErrSizeSyntax   = errors.New("invalid syntax")
+	ErrSizeUnit     = errors.New("unknown unit")
+	ErrSizeNegative = errors.New("negative size")
+	ErrSizeOverflow = errors.New("value out of range")
+)
+
+// SizeError records a failed size parse, use errors.Is() against the
+// ErrSize* values to see what went wrong
+type SizeError struct {
+	Input string
+	Err   error
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("invalid size '%s': %s", e.Input, e.Err)
+}
+
+// Unwrap returns the underlying ErrSize* error
+func (e *SizeError) Unwrap() error {
+	return e.Err
+}
+
+// ParseOptions controls how ParseSize interprets a size string, the zero
+// value is the most lenient: decimals and spaces are allowed and plain
+// suffixes (k, MB, ...) are powers of 1000
+type ParseOptions struct {
+	// Binary makes plain suffixes (k, MB, ...) powers of 1024
+	Binary bool
+	// Strict makes SI suffixes (k, MB) always powers of 1000 and IEC
+	// suffixes (Ki, MiB) always powers of 1024, ignoring Binary
+	Strict bool
+	// IntegerOnly rejects fractional values such as "1.5G"
+	IntegerOnly bool
+	// NoSpaces rejects whitespace around the value or before the unit
+	NoSpaces bool
+}
+
 // CustomSize returns a human-readable approximation of a size
-// using custom format.
+// using custom format.  Sizes beyond the last unit in the map stay in
+// that unit (eg. "1000 YB") and negative sizes are scaled like positive
+// ones (eg. "-1.5 kB"), see SizeFormatter for more options.
 func CustomSize(format string, size float64, base float64, _map []string) string {
-	i := 0
-	for size >= base {
-		size = size / base
-		i++
-	}
+	size, i := scaleNumber(size, base, 0, len(_map)-1)
 	return fmt.Sprintf(format, size, _map[i])
 }
 
 // HumanSize returns a human-readable approximation of a size
 // capped at 4 valid numbers (eg. "2.746 MB", "796 KB").
 func HumanSize(size float64) string {
-	return CustomSize("%.4g %s", size, 1000.0, decimapAbbrs)
+	return CurrentLocale().HumanSize(size)
 }
 
 // BytesSize returns a human-readable size in bytes, kibibytes,
 // mebibytes, gibibytes, or tebibytes (eg. "44kiB", "17MiB").
 func BytesSize(size float64) string {
-	return CustomSize("%.4g %s", size, 1024.0, binaryAbbrs)
+	return CurrentLocale().BytesSize(size)
+}
+
+// HumanSize is HumanSize() using this locales decimal separator and
+// unit abbreviations
+func (l *Locale) HumanSize(size float64) string {
+	return l.decimal(CustomSize("%.4g %s", size, 1000.0, l.sizeAbbrs(decimapAbbrs)))
+}
+
+// BytesSize is BytesSize() using this locales decimal separator and
+// unit abbreviations
+func (l *Locale) BytesSize(size float64) string {
+	return l.decimal(CustomSize("%.4g %s", size, 1024.0, l.sizeAbbrs(binaryAbbrs)))
+}
+
+// sizeAbbrs translates a list of unit abbreviations via the catalog
+func (l *Locale) sizeAbbrs(abbrs []string) []string {
+	translated := make([]string, len(abbrs))
+	for i, abbr := range abbrs {
+		translated[i] = l.Text("size." + abbr)
+	}
+	return translated
 }
 
 // FromHumanSize returns an integer from a human-readable specification of a
 // size using SI standard (eg. "44kB", "17MB").
 func FromHumanSize(size string) (int64, error) {
-	return parseSize(size, decimalMap)
+	return ParseSize(size, ParseOptions{IntegerOnly: true, NoSpaces: true})
 }
 
 // RAMInBytes parses a human-readable string representing an amount of RAM
@@ -71,25 +132,74 @@ func FromHumanSize(size string) (int64, error) {
 // returns the number of bytes, or -1 if the string is unparseable.
 // Units are case-insensitive, and the 'b' suffix is optional.
 func RAMInBytes(size string) (int64, error) {
-	return parseSize(size, binaryMap)
+	return ParseSize(size, ParseOptions{Binary: true, IntegerOnly: true, NoSpaces: true})
 }
 
-// Parses the human-readable size string into the amount it represents.
-func parseSize(sizeStr string, uMap unitMap) (int64, error) {
-	matches := sizeRegex.FindStringSubmatch(sizeStr)
-	if len(matches) != 3 {
-		return -1, fmt.Errorf("invalid size: '%s'", sizeStr)
+// ParseSize parses a human-readable size such as "32", "1.5G", "32 mb",
+// "32MiB" or "4Gi" into a number of bytes, returning -1 and a *SizeError
+// if it can't be parsed or doesn't fit in an int64.  Units go all the way
+// up to yottabytes, are case-insensitive (except for the strict mode rules
+// in ParseOptions) and the 'b' suffix is optional.  Fractional bytes are
+// truncated.
+func ParseSize(sizeStr string, opts ParseOptions) (int64, error) {
+	str := sizeStr
+	if !opts.NoSpaces {
+		str = strings.TrimSpace(str)
 	}
-
-	size, err := strconv.ParseInt(matches[1], 10, 0)
-	if err != nil {
-		return -1, err
+	if strings.HasPrefix(str, "-") {
+		return -1, &SizeError{sizeStr, ErrSizeNegative}
+	}
+	matches := sizeRegex.FindStringSubmatch(str)
+	if len(matches) != 6 {
+		if opts.NoSpaces || !strings.ContainsAny(str, "0123456789") {
+			return -1, &SizeError{sizeStr, ErrSizeSyntax}
+		}
+		return -1, &SizeError{sizeStr, sizeSyntaxOrUnit(str)}
+	}
+	numStr, space, prefix, iec := matches[1], matches[2], strings.ToLower(matches[3]), matches[4] != ""
+	if space != "" && opts.NoSpaces {
+		return -1, &SizeError{sizeStr, ErrSizeSyntax}
+	}
+	if opts.IntegerOnly && strings.Contains(numStr, ".") {
+		return -1, &SizeError{sizeStr, ErrSizeSyntax}
+	}
+	if iec && prefix == "" {
+		return -1, &SizeError{sizeStr, ErrSizeUnit}
 	}
 
-	unitPrefix := strings.ToLower(matches[2])
-	if mul, ok := uMap[unitPrefix]; ok {
-		size *= mul
+	base := int64(1000)
+	if iec || (opts.Binary && !opts.Strict) {
+		base = 1024
 	}
+	num, ok := new(big.Rat).SetString(numStr)
+	if !ok {
+		return -1, &SizeError{sizeStr, ErrSizeSyntax}
+	}
+	if prefix != "" {
+		power := int64(strings.Index(sizePrefixes, prefix) + 1)
+		mul := new(big.Int).Exp(big.NewInt(base), big.NewInt(power), nil)
+		num.Mul(num, new(big.Rat).SetInt(mul))
+	}
+	size := new(big.Int).Quo(num.Num(), num.Denom())
+	if !size.IsInt64() {
+		return -1, &SizeError{sizeStr, ErrSizeOverflow}
+	}
+	return size.Int64(), nil
+}
 
-	return size, nil
+// sizeSyntaxOrUnit decides if a string that didn't match the size regex
+// has a bad number or a bad unit, ie: "32 xb" is a bad unit
+func sizeSyntaxOrUnit(str string) error {
+	i := 0
+	for i < len(str) && (str[i] >= '0' && str[i] <= '9' || str[i] == '.') {
+		i++
+	}
+	if i == 0 || strings.Count(str[:i], ".") > 1 || str[:i] == "." {
+		return ErrSizeSyntax
+	}
+	unit := strings.TrimSpace(str[i:])
+	if unit == "" || strings.ContainsAny(unit, " \t") {
+		return ErrSizeSyntax
+	}
+	return ErrSizeUnit
 }
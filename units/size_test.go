@@ -16,6 +16,7 @@
 package units
 
 import (
+	"errors"
 	"reflect"
 	"runtime"
 	"strings"
@@ -55,6 +56,9 @@ func TestFromHumanSize(t *testing.T) {
 	assertSuccessEquals(t, 32*GB, FromHumanSize, "32Gb")
 	assertSuccessEquals(t, 32*TB, FromHumanSize, "32Tb")
 	assertSuccessEquals(t, 32*PB, FromHumanSize, "32Pb")
+	assertSuccessEquals(t, 32*MiB, FromHumanSize, "32MiB")
+	assertSuccessEquals(t, 4*GiB, FromHumanSize, "4Gi")
+	assertSuccessEquals(t, 9*EB, FromHumanSize, "9EB")
 
 	assertError(t, FromHumanSize, "")
 	assertError(t, FromHumanSize, "hello")
@@ -65,6 +69,7 @@ func TestFromHumanSize(t *testing.T) {
 	assertError(t, FromHumanSize, "32 mb")
 	assertError(t, FromHumanSize, "32m b")
 	assertError(t, FromHumanSize, "32bm")
+	assertError(t, FromHumanSize, "10EB")
 }
 
 func TestRAMInBytes(t *testing.T) {
@@ -81,6 +86,8 @@ func TestRAMInBytes(t *testing.T) {
 	assertSuccessEquals(t, 32*PiB, RAMInBytes, "32Pb")
 	assertSuccessEquals(t, 32*PiB, RAMInBytes, "32PB")
 	assertSuccessEquals(t, 32*PiB, RAMInBytes, "32P")
+	assertSuccessEquals(t, 32*MiB, RAMInBytes, "32MiB")
+	assertSuccessEquals(t, 2*EiB, RAMInBytes, "2E")
 
 	assertError(t, RAMInBytes, "")
 	assertError(t, RAMInBytes, "hello")
@@ -93,6 +100,84 @@ func TestRAMInBytes(t *testing.T) {
 	assertError(t, RAMInBytes, "32bm")
 }
 
+func TestParseSize(t *testing.T) {
+	lenient := ParseOptions{}
+	binary := ParseOptions{Binary: true}
+	strict := ParseOptions{Binary: true, Strict: true}
+	tests := []struct {
+		in       string
+		opts     ParseOptions
+		expected int64
+	}{
+		{"32", lenient, 32},
+		{"1.5G", lenient, 1500 * MB},
+		{"1.5G", binary, 1536 * MiB},
+		{" 32 mb ", lenient, 32 * MB},
+		{"32 mb", binary, 32 * MiB},
+		{"32MiB", lenient, 32 * MiB},
+		{"4Gi", lenient, 4 * GiB},
+		{"4gib", lenient, 4 * GiB},
+		{".5k", lenient, 500},
+		{"2.k", lenient, 2000},
+		{"1.0001kB", lenient, 1000},
+		{"2EB", lenient, 2 * EB},
+		{"7EiB", lenient, 7 * EiB},
+		{"0.000001Z", lenient, PB},
+		{"0Y", lenient, 0},
+		{"32MB", strict, 32 * MB},
+		{"32MiB", strict, 32 * MiB},
+		{"9223372036854775807", lenient, 9223372036854775807},
+	}
+	for _, test := range tests {
+		res, err := ParseSize(test.in, test.opts)
+		if err != nil || res != test.expected {
+			t.Errorf("ParseSize(\"%s\", %+v) -> expected '%d' but got '%d' with error '%v'", test.in, test.opts, test.expected, res, err)
+		}
+	}
+
+	errTests := []struct {
+		in       string
+		opts     ParseOptions
+		expected error
+	}{
+		{"", lenient, ErrSizeSyntax},
+		{"hello", lenient, ErrSizeSyntax},
+		{"1..5G", lenient, ErrSizeSyntax},
+		{"32m b", lenient, ErrSizeSyntax},
+		{"32bm", lenient, ErrSizeUnit},
+		{"32 xb", lenient, ErrSizeUnit},
+		{"32ib", lenient, ErrSizeUnit},
+		{"-32", lenient, ErrSizeNegative},
+		{" -1G", lenient, ErrSizeNegative},
+		{"8EiB", lenient, ErrSizeOverflow},
+		{"10EB", lenient, ErrSizeOverflow},
+		{"1Y", lenient, ErrSizeOverflow},
+		{"9223372036854775808", lenient, ErrSizeOverflow},
+		{"1.5G", ParseOptions{IntegerOnly: true}, ErrSizeSyntax},
+		{"32 mb", ParseOptions{NoSpaces: true}, ErrSizeSyntax},
+		{" 32", ParseOptions{NoSpaces: true}, ErrSizeSyntax},
+	}
+	for _, test := range errTests {
+		res, err := ParseSize(test.in, test.opts)
+		if res != -1 || !errors.Is(err, test.expected) {
+			t.Errorf("ParseSize(\"%s\", %+v) -> expected error '%v' but got '%d' with error '%v'", test.in, test.opts, test.expected, res, err)
+		}
+		if _, ok := err.(*SizeError); !ok {
+			t.Errorf("ParseSize(\"%s\") -> expected a *SizeError but got %T", test.in, err)
+		}
+	}
+}
+
+func TestParseSizeInBytes(t *testing.T) {
+	assertEquals(t, uint(32), ParseSizeInBytes("32"))
+	assertEquals(t, uint(12*MiB), ParseSizeInBytes("12 mb"))
+	assertEquals(t, uint(1*GiB), ParseSizeInBytes("1GB"))
+	assertEquals(t, uint(1536*KiB), ParseSizeInBytes("1.5 MiB"))
+	assertEquals(t, uint(0), ParseSizeInBytes("garbage"))
+	assertEquals(t, uint(0), ParseSizeInBytes("-5MB"))
+	assertEquals(t, uint(0), ParseSizeInBytes("100EB"))
+}
+
 func assertEquals(t *testing.T, expected, actual interface{}) {
 	if expected != actual {
 		t.Errorf("Expected '%v' but got '%v'", expected, actual)
@@ -0,0 +1,159 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// UnitStyle picks the unit names used by a SizeFormatter
+type UnitStyle int
+
+// Unit styles available for a SizeFormatter, UnitAuto uses UnitSI for a
+// base of 1000 and UnitIEC for a base of 1024
+const (
+	UnitAuto  UnitStyle = iota
+	UnitSI              // B, kB, MB, GB, ...
+	UnitIEC             // B, KiB, MiB, GiB, ...
+	UnitJEDEC           // B, KB, MB, GB, ... (usually with a base of 1024)
+	UnitLong            // bytes, kilobytes/kibibytes, ...
+)
+
+var jedecAbbrs = []string{"B", "KB", "MB", "GB", "TB", "PB", "EB", "ZB", "YB"}
+
+// long unit names, these are looked up in the locale catalog as "size.<name>"
+var decimalLong = []string{"byte", "kilobyte", "megabyte", "gigabyte", "terabyte", "petabyte", "exabyte", "zettabyte", "yottabyte"}
+var binaryLong = []string{"byte", "kibibyte", "mebibyte", "gibibyte", "tebibyte", "pebibyte", "exbibyte", "zebibyte", "yobibyte"}
+
+// SizeFormatter formats byte sizes with more control than HumanSize() or
+// BytesSize(), the zero value gives base 1000 SI units with no decimals
+// (eg. "3 MB"), see NewSizeFormatter() for friendlier defaults.
+type SizeFormatter struct {
+	Base             int       // 1000 (default) or 1024
+	Precision        int       // number of decimals to round to
+	TrimZeros        bool      // drop trailing zero decimals ("1.50 MB" -> "1.5 MB")
+	Style            UnitStyle // unit names to use
+	NoSpace          bool      // no space between the number and unit ("1.5MB")
+	DecimalSeparator string    // decimal separator, default is the locales (eg: "," for de)
+	Width            int       // right align the result to this width, 0 for none
+	Locale           string    // locale for unit names and separators, "" for CurrentLocale()
+}
+
+// NewSizeFormatter returns a formatter for the given base (1000 or 1024)
+// that rounds to 2 decimals and drops trailing zeros (eg. "1.5 MiB")
+func NewSizeFormatter(base int) SizeFormatter {
+	return SizeFormatter{Base: base, Precision: 2, TrimZeros: true}
+}
+
+// Format returns the formatted size, negative sizes are formatted like
+// positive ones with a leading "-" and values beyond the largest unit
+// stay in that unit rather than running off the end of the unit list
+func (f SizeFormatter) Format(size float64) string {
+	if math.IsNaN(size) {
+		return f.pad("NaN")
+	}
+	base := float64(1000)
+	if f.Base == 1024 {
+		base = 1024
+	}
+	units := f.units()
+	prec := f.Precision
+	if prec < 0 {
+		prec = 0
+	}
+
+	neg := size < 0
+	size = math.Abs(size)
+	i := 0
+	for size >= base && i < len(units)-1 {
+		size /= base
+		i++
+	}
+	// rounding can push us up to the next unit (999.999 kB -> 1000.00 kB)
+	if i < len(units)-1 && roundTo(size, prec) >= base {
+		size /= base
+		i++
+	}
+	num := strconv.FormatFloat(size, 'f', prec, 64)
+	if f.TrimZeros && strings.Contains(num, ".") {
+		num = strings.TrimRight(strings.TrimRight(num, "0"), ".")
+	}
+	rounded, _ := strconv.ParseFloat(num, 64)
+	nonZero := rounded != 0
+
+	loc := localeOrCurrent(f.Locale)
+	decSep := f.DecimalSeparator
+	if decSep == "" {
+		decSep = loc.DecimalSeparator
+	}
+	if decSep != "" && decSep != "." {
+		num = strings.Replace(num, ".", decSep, 1)
+	}
+	if neg && nonZero {
+		num = "-" + num
+	}
+	if f.style() == UnitLong {
+		return f.pad(loc.CountString("size."+units[i], rounded, num))
+	}
+	sep := " "
+	if f.NoSpace {
+		sep = ""
+	}
+	return f.pad(num + sep + loc.Text("size."+units[i]))
+}
+
+// style resolves UnitAuto into a real style based on the base
+func (f SizeFormatter) style() UnitStyle {
+	if f.Style != UnitAuto {
+		return f.Style
+	}
+	if f.Base == 1024 {
+		return UnitIEC
+	}
+	return UnitSI
+}
+
+// units returns the unit names for the style and base in use
+func (f SizeFormatter) units() []string {
+	switch f.style() {
+	case UnitIEC:
+		return binaryAbbrs
+	case UnitJEDEC:
+		return jedecAbbrs
+	case UnitLong:
+		if f.Base == 1024 {
+			return binaryLong
+		}
+		return decimalLong
+	}
+	return decimapAbbrs
+}
+
+// pad right aligns the string to the formatters width
+func (f SizeFormatter) pad(s string) string {
+	if n := f.Width - len([]rune(s)); n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
+
+// roundTo rounds the value to the given number of decimals
+func roundTo(val float64, prec int) float64 {
+	pow := math.Pow(10, float64(prec))
+	return math.Round(val*pow) / pow
+}
@@ -0,0 +1,73 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSizeFormatter(t *testing.T) {
+	var zero SizeFormatter
+	assertEquals(t, "3 MB", zero.Format(3.2*MB))
+	assertEquals(t, "0 B", zero.Format(0))
+
+	si := NewSizeFormatter(1000)
+	assertEquals(t, "1.5 MB", si.Format(1.5*MB))
+	assertEquals(t, "1 kB", si.Format(1000))
+	assertEquals(t, "1.02 kB", si.Format(1024))
+	assertEquals(t, "999 B", si.Format(999))
+	assertEquals(t, "1 MB", si.Format(999999))
+	assertEquals(t, "-2.5 GB", si.Format(-2.5*GB))
+	assertEquals(t, "0 B", si.Format(-0.001))
+
+	iec := NewSizeFormatter(1024)
+	assertEquals(t, "1.5 MiB", iec.Format(1.5*MiB))
+	assertEquals(t, "3.42 GiB", iec.Format(3.42*GiB))
+	iec.TrimZeros = false
+	assertEquals(t, "2.00 MiB", iec.Format(2*MiB))
+	iec.Precision = 3
+	assertEquals(t, "1.000 KiB", iec.Format(1024))
+
+	jedec := SizeFormatter{Base: 1024, Precision: 1, Style: UnitJEDEC, NoSpace: true}
+	assertEquals(t, "1.5KB", jedec.Format(1536))
+
+	long := SizeFormatter{Base: 1000, Precision: 1, TrimZeros: true, Style: UnitLong}
+	assertEquals(t, "1 byte", long.Format(1))
+	assertEquals(t, "12 bytes", long.Format(12))
+	assertEquals(t, "1 kilobyte", long.Format(1000))
+	assertEquals(t, "2.5 megabytes", long.Format(2.5*MB))
+	long.Base = 1024
+	assertEquals(t, "3 gibibytes", long.Format(3*GiB))
+
+	de := SizeFormatter{Base: 1000, Precision: 2, DecimalSeparator: ","}
+	assertEquals(t, "1,50 MB", de.Format(1.5*MB))
+
+	padded := SizeFormatter{Base: 1024, Precision: 1, Width: 10}
+	assertEquals(t, "   1.0 KiB", padded.Format(1024))
+	assertEquals(t, " 512.0 MiB", padded.Format(512*MiB))
+	assertEquals(t, "1000.0 YiB", padded.Format(1000*math.Pow(1024, 8)))
+}
+
+func TestSizeFormatterHuge(t *testing.T) {
+	si := NewSizeFormatter(1000)
+	assertEquals(t, "1 YB", si.Format(1e24))
+	assertEquals(t, "2000 YB", si.Format(2e27))
+	assertEquals(t, "-2000 YB", si.Format(-2e27))
+	// CustomSize should no longer index off the end of the unit list
+	assertEquals(t, "2000 YB", HumanSize(2e27))
+	assertEquals(t, "5000 GB", CustomSize("%.4g %s", 5e6, 1000, []string{"MB", "GB"}))
+}
@@ -0,0 +1,166 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// SizeBase controls how Size values are printed and how plain suffixes
+// (k, MB, ...) are parsed: 1024 (the default) prints via BytesSize() and
+// treats "10G" as 10 GiB, 1000 prints via HumanSize() and treats "10G"
+// as 10 GB.  IEC suffixes ("10GiB") always mean powers of 1024 and the
+// marshalled forms only use those (or plain bytes) so they read back the
+// same whatever SizeBase is.
+var SizeBase = 1024
+
+// Size is a byte count that can be used directly as a command line flag
+// (flag.Value, including the pflag Type() method) or in JSON, YAML or any
+// other text based config (eg: "cache_limit: 10GiB").
+type Size int64
+
+// ParseSizeValue parses a size string into a Size using SizeBase, see
+// ParseSize() for the accepted formats
+func ParseSizeValue(s string) (Size, error) {
+	size, err := ParseSize(s, ParseOptions{Binary: SizeBase != 1000})
+	if err != nil {
+		return 0, err
+	}
+	return Size(size), nil
+}
+
+// Bytes returns the size as a plain int64
+func (s Size) Bytes() int64 {
+	return int64(s)
+}
+
+// String returns a human-readable approximation of the size, via
+// BytesSize() or HumanSize() depending on SizeBase (eg. "10 GiB")
+func (s Size) String() string {
+	if SizeBase == 1000 {
+		return HumanSize(float64(s))
+	}
+	return BytesSize(float64(s))
+}
+
+// Set parses the given string into the size, for flag.Value
+func (s *Size) Set(value string) error {
+	size, err := ParseSizeValue(value)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+// Type returns the type name for pflag's flag usage output
+func (s *Size) Type() string {
+	return "size"
+}
+
+// MarshalText writes the size using the largest IEC unit that represents
+// it exactly (eg: "10GiB" or "12345") so that it round trips without
+// losing any bytes, unlike String().  Negative sizes can't be parsed back
+// so they give a *SizeError (ErrSizeNegative) instead.
+func (s Size) MarshalText() ([]byte, error) {
+	str, err := s.exactString()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(str), nil
+}
+
+// UnmarshalText parses a size string, see ParseSize() for the formats
+func (s *Size) UnmarshalText(text []byte) error {
+	return s.Set(string(text))
+}
+
+// MarshalJSON writes the size as a JSON string using MarshalText()
+func (s Size) MarshalJSON() ([]byte, error) {
+	str, err := s.exactString()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(str)
+}
+
+// UnmarshalJSON accepts either a JSON string ("10GiB") or a plain
+// number of bytes
+func (s *Size) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		return s.Set(str)
+	}
+	size, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid size %s: must be a string or an integer number of bytes", data)
+	}
+	return s.setBytes(size)
+}
+
+// MarshalYAML writes the size as a YAML string using MarshalText()
+func (s Size) MarshalYAML() (interface{}, error) {
+	return s.exactString()
+}
+
+// UnmarshalYAML accepts either a size string or a plain number of bytes
+// (this is the yaml.v2 style interface which yaml.v3 also honors)
+func (s *Size) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var size int64
+	if err := unmarshal(&size); err == nil {
+		return s.setBytes(size)
+	}
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	return s.Set(str)
+}
+
+// setBytes sets a plain byte count, rejecting negative ones the same as
+// ParseSize() does for strings
+func (s *Size) setBytes(size int64) error {
+	if size < 0 {
+		return &SizeError{strconv.FormatInt(size, 10), ErrSizeNegative}
+	}
+	*s = Size(size)
+	return nil
+}
+
+// exactString formats the size with the biggest IEC unit that divides it
+// evenly, falling back to a plain byte count, negative sizes are an error
+func (s Size) exactString() (string, error) {
+	n := int64(s)
+	if n < 0 {
+		return "", &SizeError{strconv.FormatInt(n, 10), ErrSizeNegative}
+	}
+	i := 0
+	for n != 0 && n%1024 == 0 && i < len(binaryAbbrs)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return strconv.FormatInt(n, 10), nil
+	}
+	return strconv.FormatInt(n, 10) + binaryAbbrs[i], nil
+}
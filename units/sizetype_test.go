@@ -0,0 +1,166 @@
+// These are various utility routines from docker, viper and various other
+// tools/packages along with any local additions/mods.  For any local mods
+// the Apache license is included:
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package units
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+func TestSizeFlag(t *testing.T) {
+	var limit Size
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(&limit, "cache-limit", "cache size limit")
+	if err := fs.Parse([]string{"-cache-limit", "10GiB"}); err != nil {
+		t.Fatalf("Unexpected flag parse error: %s", err)
+	}
+	assertEquals(t, Size(10*GiB), limit)
+	assertEquals(t, "10 GiB", limit.String())
+	assertEquals(t, "size", limit.Type())
+
+	if err := fs.Parse([]string{"-cache-limit", "1.5G"}); err != nil {
+		t.Fatalf("Unexpected flag parse error: %s", err)
+	}
+	assertEquals(t, Size(1536*MiB), limit)
+	if err := fs.Parse([]string{"-cache-limit", "lots"}); err == nil {
+		t.Fatal("Expected a flag parse error for a bad size")
+	}
+}
+
+func TestSizeBase(t *testing.T) {
+	defer func(orig int) { SizeBase = orig }(SizeBase)
+	SizeBase = 1000
+	size, err := ParseSizeValue("10G")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEquals(t, Size(10*GB), size)
+	assertEquals(t, "10 GB", size.String())
+	text, _ := Size(1500).MarshalText()
+	assertEquals(t, "1500", string(text))
+	text, _ = Size(1500 * KB).MarshalText()
+	assertEquals(t, "1500000", string(text))
+	text, _ = Size(2 * GiB).MarshalText()
+	assertEquals(t, "2GiB", string(text))
+	size, _ = ParseSizeValue("4GiB")
+	assertEquals(t, Size(4*GiB), size)
+
+	// text marshalled under one base reads back the same under the other
+	SizeBase = 1024
+	var back Size
+	if err := back.UnmarshalText([]byte("1500000")); err != nil || back != Size(1500*KB) {
+		t.Fatalf("Expected 1500000 to read back as %d, got %d (err: %v)", 1500*KB, back, err)
+	}
+}
+
+func TestSizeText(t *testing.T) {
+	tests := map[Size]string{
+		0:          "0",
+		1023:       "1023",
+		1024:       "1KiB",
+		10 * GiB:   "10GiB",
+		1536 * MiB: "1536MiB",
+		3*GiB + 1:  "3221225473",
+		7 * EiB:    "7EiB",
+		PiB:        "1PiB",
+	}
+	for size, expected := range tests {
+		text, err := size.MarshalText()
+		if err != nil || string(text) != expected {
+			t.Errorf("MarshalText(%d) -> expected '%s' but got '%s' with error '%v'", size, expected, text, err)
+		}
+		var back Size
+		if err := back.UnmarshalText(text); err != nil || back != size {
+			t.Errorf("UnmarshalText(%s) -> expected '%d' but got '%d' with error '%v'", text, size, back, err)
+		}
+	}
+
+	// negative sizes can't be parsed back so they don't marshal either
+	neg := Size(-KiB)
+	if _, err := neg.MarshalText(); !errors.Is(err, ErrSizeNegative) {
+		t.Errorf("MarshalText(%d) -> expected a negative size error, got: %v", neg, err)
+	}
+	if _, err := json.Marshal(neg); !errors.Is(err, ErrSizeNegative) {
+		t.Errorf("MarshalJSON(%d) -> expected a negative size error, got: %v", neg, err)
+	}
+	if _, err := neg.MarshalYAML(); !errors.Is(err, ErrSizeNegative) {
+		t.Errorf("MarshalYAML(%d) -> expected a negative size error, got: %v", neg, err)
+	}
+}
+
+func TestSizeJSON(t *testing.T) {
+	type config struct {
+		CacheLimit Size `json:"cache_limit"`
+		MaxFile    Size `json:"max_file"`
+	}
+	var cfg config
+	if err := json.Unmarshal([]byte(`{"cache_limit": "10GiB", "max_file": 4096}`), &cfg); err != nil {
+		t.Fatalf("Unexpected JSON unmarshal error: %s", err)
+	}
+	assertEquals(t, Size(10*GiB), cfg.CacheLimit)
+	assertEquals(t, Size(4096), cfg.MaxFile)
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected JSON marshal error: %s", err)
+	}
+	assertEquals(t, `{"cache_limit":"10GiB","max_file":"4KiB"}`, string(data))
+
+	for _, bad := range []string{`{"cache_limit": "huge"}`, `{"cache_limit": 1.5}`, `{"cache_limit": true}`, `{"cache_limit": -1}`, `{"cache_limit": "-1"}`} {
+		if err := json.Unmarshal([]byte(bad), &cfg); err == nil {
+			t.Errorf("Expected JSON unmarshal error for %s", bad)
+		}
+	}
+}
+
+func TestSizeYAML(t *testing.T) {
+	// fake the yaml decoder handing us a string and then an int
+	var size Size
+	err := size.UnmarshalYAML(func(v interface{}) error {
+		switch p := v.(type) {
+		case *string:
+			*p = "2MiB"
+			return nil
+		case *int64:
+			return errors.New("cannot unmarshal !!str into int64")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected YAML unmarshal error: %s", err)
+	}
+	assertEquals(t, Size(2*MiB), size)
+	err = size.UnmarshalYAML(func(v interface{}) error {
+		*(v.(*int64)) = 42
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected YAML unmarshal error: %s", err)
+	}
+	assertEquals(t, Size(42), size)
+	err = size.UnmarshalYAML(func(v interface{}) error {
+		*(v.(*int64)) = -42
+		return nil
+	})
+	if !errors.Is(err, ErrSizeNegative) {
+		t.Fatalf("Expected a negative size error from YAML, got: %v", err)
+	}
+	out, _ := Size(5 * GiB).MarshalYAML()
+	assertEquals(t, "5GiB", out)
+}
@@ -14,55 +14,16 @@
 // limitations under the License.
 
 // Package units provides helper function to parse and print byte size and time
-// units into or from human-readable format as well as a safe multiple routine
+// units into or from human-readable format
 package units
 
-import (
-	"strings"
-	"unicode"
-
-	"github.com/dvln/cast"
-)
-
-func safeMul(a, b uint) uint {
-	c := a * b
-	if a > 1 && b > 1 && c/b != a {
-		return 0
-	}
-	return c
-}
-
-// ParseSizeInBytes converts strings like 1GB or 12 mb into an unsigned integer number of bytes
+// ParseSizeInBytes converts strings like 1GB or 12 mb into an unsigned integer
+// number of bytes (using powers of 1024), anything unparseable, negative or
+// too large gives 0.  See ParseSize() if you need to know what went wrong.
 func ParseSizeInBytes(sizeStr string) uint {
-	sizeStr = strings.TrimSpace(sizeStr)
-	lastChar := len(sizeStr) - 1
-	multiplier := uint(1)
-
-	if lastChar > 0 {
-		if sizeStr[lastChar] == 'b' || sizeStr[lastChar] == 'B' {
-			if lastChar > 1 {
-				switch unicode.ToLower(rune(sizeStr[lastChar-1])) {
-				case 'k':
-					multiplier = 1 << 10
-					sizeStr = strings.TrimSpace(sizeStr[:lastChar-1])
-				case 'm':
-					multiplier = 1 << 20
-					sizeStr = strings.TrimSpace(sizeStr[:lastChar-1])
-				case 'g':
-					multiplier = 1 << 30
-					sizeStr = strings.TrimSpace(sizeStr[:lastChar-1])
-				default:
-					multiplier = 1
-					sizeStr = strings.TrimSpace(sizeStr[:lastChar])
-				}
-			}
-		}
-	}
-
-	size := cast.ToInt(sizeStr)
-	if size < 0 {
-		size = 0
+	size, err := ParseSize(sizeStr, ParseOptions{Binary: true})
+	if err != nil {
+		return 0
 	}
-
-	return safeMul(uint(size), multiplier)
+	return uint(size)
 }
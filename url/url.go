@@ -10,22 +10,274 @@
 // limitations under the License.
 
 // Package url (github.com/dvln/util/url) is for basic URL parsing
-// or manipulation helper functions.
+// or manipulation helper functions, Parse() understands regular URLs
+// along with the scp style ssh remotes, Windows/UNC paths and plain local
+// paths that get used where a URL is expected and ParseVCS() picks apart
+// git/hg/svn/bzr remotes.
 package url
 
 import (
+	"fmt"
+	neturl "net/url"
+	"path"
+	"regexp"
 	"strings"
 )
 
+// Kind is the form a parsed URL was given in
+type Kind int
+
+// The forms Parse() recognizes
+const (
+	KindURL         Kind = iota // scheme://host/path or scheme:opaque (ie: mailto:)
+	KindSCP                     // [user@]host:path as used by ssh/git
+	KindWindowsPath             // C:\dir\file or C:/dir/file
+	KindUNC                     // \\server\share\path
+	KindLocalPath               // /abs/path or rel/path
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindURL:
+		return "url"
+	case KindSCP:
+		return "scp"
+	case KindWindowsPath:
+		return "windows-path"
+	case KindUNC:
+		return "unc"
+	case KindLocalPath:
+		return "local-path"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// URL is a parsed URL, remote or path.  Scp style remotes get an "ssh"
+// scheme and local paths (all three kinds) a "file" one, Path holds the
+// path as given for those (ie: with backslashes for Windows paths) and
+// Host the server name for UNC paths.
+type URL struct {
+	Kind     Kind
+	Scheme   string
+	User     string
+	Password string
+	Host     string // host name or IP, without brackets for IPv6 or the port
+	Port     string
+	Path     string
+	Opaque   string // the part after "scheme:" for URLs like mailto:x@y.org
+	RawQuery string // query without the "?"
+	Fragment string
+}
+
+var (
+	schemeRegex  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+	scpRegex     = regexp.MustCompile(`^(?:([^@/]+)@)?(\[[^\]/]+\]|[^:/\\]+):(.*)$`)
+	winPathRegex = regexp.MustCompile(`^[A-Za-z]:([\\/]|$)`)
+)
+
+// opaqueSchemes are the common schemes written without "//" that would
+// otherwise look like an scp style "host:path"
+var opaqueSchemes = map[string]bool{
+	"mailto": true, "urn": true, "tel": true, "data": true, "news": true,
+	"sms": true, "magnet": true, "sip": true, "sips": true, "xmpp": true,
+}
+
+// Parse parses a URL in any of the forms in Kind, a standard URL is
+// anything with a "scheme://" or a known opaque scheme (mailto:, urn:,
+// tel:, ...), "[user@]host:path" with no "/" before the ":" is an scp
+// style remote and anything else is a local path
+func Parse(raw string) (*URL, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return nil, fmt.Errorf("invalid URL '%s': empty", raw)
+	}
+	switch {
+	case strings.HasPrefix(s, `\\`):
+		return parseUNC(s)
+	case winPathRegex.MatchString(s):
+		return &URL{Kind: KindWindowsPath, Scheme: "file", Path: s}, nil
+	}
+	if scheme := schemeRegex.FindString(s); scheme != "" {
+		name := strings.ToLower(scheme[:len(scheme)-1])
+		rest := s[len(scheme):]
+		if strings.HasPrefix(rest, "//") || opaqueSchemes[name] {
+			return parseStandard(raw, s)
+		}
+	}
+	if m := scpRegex.FindStringSubmatch(s); m != nil && !strings.HasPrefix(m[3], "//") {
+		return &URL{Kind: KindSCP, Scheme: "ssh", User: m[1], Host: strings.Trim(m[2], "[]"), Path: m[3]}, nil
+	}
+	return &URL{Kind: KindLocalPath, Scheme: "file", Path: s}, nil
+}
+
+// parseStandard fills a URL from net/url for the scheme:// forms
+func parseStandard(raw, s string) (*URL, error) {
+	u, err := neturl.Parse(s)
+	if err != nil {
+		if ue, ok := err.(*neturl.Error); ok {
+			err = ue.Err
+		}
+		return nil, fmt.Errorf("invalid URL '%s': %s", raw, err)
+	}
+	out := &URL{
+		Kind:     KindURL,
+		Scheme:   u.Scheme,
+		Host:     u.Hostname(),
+		Port:     u.Port(),
+		Path:     u.Path,
+		Opaque:   u.Opaque,
+		RawQuery: u.RawQuery,
+		Fragment: u.Fragment,
+	}
+	if u.User != nil {
+		out.User = u.User.Username()
+		out.Password, _ = u.User.Password()
+	}
+	return out, nil
+}
+
+// parseUNC splits \\server\share\path into the host and path
+func parseUNC(s string) (*URL, error) {
+	rest := s[2:]
+	i := strings.IndexByte(rest, '\\')
+	if i == 0 || rest == "" {
+		return nil, fmt.Errorf("invalid UNC path '%s': missing server", s)
+	}
+	host, p := rest, `\`
+	if i > 0 {
+		host, p = rest[:i], rest[i:]
+	}
+	return &URL{Kind: KindUNC, Scheme: "file", Host: host, Path: p}, nil
+}
+
+// Query parses RawQuery into its values
+func (u *URL) Query() neturl.Values {
+	q, _ := neturl.ParseQuery(u.RawQuery)
+	return q
+}
+
+// IsLocal checks if the URL is a local (or UNC) path rather than a remote
+func (u *URL) IsLocal() bool {
+	return u.Kind == KindWindowsPath || u.Kind == KindUNC || u.Kind == KindLocalPath
+}
+
+// hostPort joins the host and port, adding brackets for IPv6 hosts
+func (u *URL) hostPort() string {
+	host := u.Host
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if u.Port != "" {
+		host += ":" + u.Port
+	}
+	return host
+}
+
+// String puts the URL back together in the form it was given in
+func (u *URL) String() string {
+	switch u.Kind {
+	case KindSCP:
+		host := u.Host
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		if u.User != "" {
+			host = u.User + "@" + host
+		}
+		return host + ":" + u.Path
+	case KindWindowsPath, KindLocalPath:
+		return u.Path
+	case KindUNC:
+		return `\\` + u.Host + u.Path
+	}
+	nu := &neturl.URL{
+		Scheme:   u.Scheme,
+		Opaque:   u.Opaque,
+		Host:     u.hostPort(),
+		Path:     u.Path,
+		RawQuery: u.RawQuery,
+		Fragment: u.Fragment,
+	}
+	if u.Password != "" {
+		nu.User = neturl.UserPassword(u.User, u.Password)
+	} else if u.User != "" {
+		nu.User = neturl.User(u.User)
+	}
+	return nu.String()
+}
+
+// defaultPorts are left out by Normalize()
+var defaultPorts = map[string]string{
+	"http": "80", "https": "443", "ssh": "22", "git": "9418", "ftp": "21",
+	"ws": "80", "wss": "443", "svn": "3690",
+}
+
+// Normalize returns a canonical copy of the URL for comparisons: the
+// scheme and host are lower cased, default ports dropped, "." and ".."
+// path segments resolved, trailing slashes removed (an http(s) URL keeps
+// a "/" path) and query parameters sorted.  Windows paths get backslashes
+// and an upper case drive letter.
+func (u *URL) Normalize() *URL {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	switch n.Kind {
+	case KindWindowsPath:
+		p := strings.Replace(n.Path, `\`, "/", -1)
+		p = strings.ToUpper(p[:1]) + cleanPath(p[1:])
+		n.Path = strings.Replace(p, "/", `\`, -1)
+		return &n
+	case KindUNC:
+		n.Path = strings.Replace(cleanPath(strings.Replace(n.Path, `\`, "/", -1)), "/", `\`, -1)
+		return &n
+	}
+	if n.Port == defaultPorts[n.Scheme] {
+		n.Port = ""
+	}
+	if n.Path != "" {
+		n.Path = cleanPath(n.Path)
+	}
+	if n.Kind == KindURL && n.Opaque == "" && n.Path == "" && (n.Scheme == "http" || n.Scheme == "https") {
+		n.Path = "/"
+	}
+	if n.RawQuery != "" {
+		if q, err := neturl.ParseQuery(n.RawQuery); err == nil {
+			n.RawQuery = q.Encode()
+		}
+	}
+	return &n
+}
+
+// cleanPath resolves "." and ".." and drops trailing slashes, keeping
+// relative paths relative
+func cleanPath(p string) string {
+	clean := path.Clean(p)
+	if clean == "." {
+		return ""
+	}
+	return clean
+}
+
+// Normalize parses the URL and returns its canonical form, two URLs for
+// the same thing should give the same string, see URL.Normalize()
+func Normalize(raw string) (string, error) {
+	u, err := Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	return u.Normalize().String(), nil
+}
+
 // GetScheme tries to parse the given URL (string) to see if it has
 // a scheme (eg: in "https://github.com/dvln/dvln" the scheme would
-// be "https", in "github.com/dvln/dvln" there is no scheme).  If
-// there is no scheme then "" is returned, otherwise the scheme in
-// use is returned.
+// be "https", in "mailto:dev@dvln.org" it's "mailto" and for an scp
+// style "git@github.com:dvln/dvln" it's "ssh", in "github.com/dvln/dvln"
+// there is no scheme).  If there is no scheme then "" is returned,
+// otherwise the scheme in use is returned.
 func GetScheme(url string) string {
-	parts := strings.Split(url, "://")
-	if len(parts) >= 2 {
-		return parts[0]
+	u, err := Parse(url)
+	if err != nil || u.IsLocal() {
+		return ""
 	}
-	return ""
+	return u.Scheme
 }
@@ -29,4 +29,90 @@ func TestGetScheme(t *testing.T) {
 	if scheme != "" {
 		t.Fatalf("Failed to parse URL 2 (%s) correctly, scheme should have been \"\", was: %s", url1, scheme)
 	}
+
+	for url, expected := range map[string]string{
+		"mailto:dev@dvln.org":                   "mailto",
+		"git@github.com:dvln/dvln.git":          "ssh",
+		"dir/file?next=http://x.org/y":          "",
+		"C:\\dvln\\src":                         "",
+		"https://x.org/login?next=ftp://y.org/": "https",
+	} {
+		if scheme = GetScheme(url); scheme != expected {
+			t.Fatalf("GetScheme(%s): expected %q, was: %q", url, expected, scheme)
+		}
+	}
+}
+
+// TestParse checks the various URL, remote and path forms are split up
+func TestParse(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected URL
+	}{
+		{"https://user:pw@GitHub.com:8443/dvln/dvln?a=1&b=2#top", URL{Kind: KindURL, Scheme: "https", User: "user", Password: "pw", Host: "GitHub.com", Port: "8443", Path: "/dvln/dvln", RawQuery: "a=1&b=2", Fragment: "top"}},
+		{"http://x.org/redir?to=https://y.org/z", URL{Kind: KindURL, Scheme: "http", Host: "x.org", Path: "/redir", RawQuery: "to=https://y.org/z"}},
+		{"ssh://git@[::1]:2222/repo.git", URL{Kind: KindURL, Scheme: "ssh", User: "git", Host: "::1", Port: "2222", Path: "/repo.git"}},
+		{"mailto:dev@dvln.org?subject=hi", URL{Kind: KindURL, Scheme: "mailto", Opaque: "dev@dvln.org", RawQuery: "subject=hi"}},
+		{"file:///tmp/x", URL{Kind: KindURL, Scheme: "file", Path: "/tmp/x"}},
+		{"git@github.com:dvln/dvln.git", URL{Kind: KindSCP, Scheme: "ssh", User: "git", Host: "github.com", Path: "dvln/dvln.git"}},
+		{"github.com:dvln/dvln", URL{Kind: KindSCP, Scheme: "ssh", Host: "github.com", Path: "dvln/dvln"}},
+		{"[::1]:repo", URL{Kind: KindSCP, Scheme: "ssh", Host: "::1", Path: "repo"}},
+		{"C:\\dvln\\src", URL{Kind: KindWindowsPath, Scheme: "file", Path: "C:\\dvln\\src"}},
+		{"d:/dvln", URL{Kind: KindWindowsPath, Scheme: "file", Path: "d:/dvln"}},
+		{"\\\\server\\share\\dir", URL{Kind: KindUNC, Scheme: "file", Host: "server", Path: "\\share\\dir"}},
+		{"/abs/path:with/colon", URL{Kind: KindLocalPath, Scheme: "file", Path: "/abs/path:with/colon"}},
+		{"./rel/dir", URL{Kind: KindLocalPath, Scheme: "file", Path: "./rel/dir"}},
+		{"github.com/dvln/dvln", URL{Kind: KindLocalPath, Scheme: "file", Path: "github.com/dvln/dvln"}},
+		{"dir/file?next=http://x.org/y", URL{Kind: KindLocalPath, Scheme: "file", Path: "dir/file?next=http://x.org/y"}},
+	}
+	for _, test := range tests {
+		u, err := Parse(test.raw)
+		if err != nil {
+			t.Fatalf("Parse(%s) failed: %s", test.raw, err)
+		}
+		if *u != test.expected {
+			t.Fatalf("Parse(%s):\n expected: %+v\n      was: %+v", test.raw, test.expected, *u)
+		}
+		if str := u.String(); str != test.raw {
+			t.Fatalf("Parse(%s).String(): expected the input back, was: %s", test.raw, str)
+		}
+	}
+	if q := (&URL{RawQuery: "a=1&a=2"}).Query(); len(q["a"]) != 2 {
+		t.Fatalf("Query(): expected two values for a, was: %v", q)
+	}
+
+	for _, bad := range []string{"", "   ", "http://x.org/%zz", "\\\\"} {
+		if _, err := Parse(bad); err == nil {
+			t.Fatalf("Parse(%q): expected an error", bad)
+		}
+	}
+}
+
+// TestNormalize checks equivalent URLs normalize to the same string
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw, expected string
+	}{
+		{"HTTPS://GitHub.COM:443/dvln/./dvln/", "https://github.com/dvln/dvln"},
+		{"http://x.org", "http://x.org/"},
+		{"http://x.org:8080/a/../b?z=1&a=2", "http://x.org:8080/b?a=2&z=1"},
+		{"ssh://git@Host:22/repo.git/", "ssh://git@host/repo.git"},
+		{"git@GitHub.com:dvln//dvln/", "git@github.com:dvln/dvln"},
+		{"c:/dvln/../src/", "C:\\src"},
+		{"\\\\Server\\share\\a\\..\\b", "\\\\server\\share\\b"},
+		{"./a/b/../c/", "a/c"},
+		{"MAILTO:dev@dvln.org", "mailto:dev@dvln.org"},
+	}
+	for _, test := range tests {
+		n, err := Normalize(test.raw)
+		if err != nil {
+			t.Fatalf("Normalize(%s) failed: %s", test.raw, err)
+		}
+		if n != test.expected {
+			t.Fatalf("Normalize(%s): expected %s, was: %s", test.raw, test.expected, n)
+		}
+	}
+	if _, err := Normalize(""); err == nil {
+		t.Fatalf("Normalize(\"\"): expected an error")
+	}
 }
@@ -0,0 +1,237 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package url
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VCSRemote is a parsed version control remote, Owner is everything in
+// the path before the repo name (it may have slashes, ie: for gitlab
+// subgroups) and Repo is the name without any ".git" suffix.  Local
+// remotes (paths and file:// URLs) have no Owner, just the Repo name.
+type VCSRemote struct {
+	URL       *URL
+	VCS       string // "git", "hg", "svn", "bzr" or "" if it couldn't be told
+	Host      string
+	Port      string
+	User      string
+	Owner     string
+	Repo      string
+	GitSuffix bool // the remote path ended in ".git"
+}
+
+// vcsSchemes maps the VCS specific schemes to their VCS
+var vcsSchemes = map[string]string{
+	"git": "git", "git+ssh": "git", "ssh+git": "git",
+	"hg": "hg", "hg+ssh": "hg", "ssh+hg": "hg",
+	"svn": "svn", "svn+ssh": "svn",
+	"bzr": "bzr", "bzr+ssh": "bzr",
+}
+
+// vcsHosts are well known hosting sites and the VCS they serve
+var vcsHosts = map[string]string{
+	"github.com": "git", "gitlab.com": "git", "bitbucket.org": "git",
+	"launchpad.net": "bzr", "code.launchpad.net": "bzr", "bazaar.launchpad.net": "bzr",
+}
+
+// ParseVCS parses a git, hg, svn or bzr remote given as an https, ssh,
+// scp style (git@host:owner/repo.git), git://, file:// URL or a local
+// path.  The VCS is worked out from the scheme, a ".git" suffix, scp
+// style (git only), well known hosts or a "git."/"hg."/"svn."/"bzr."
+// host or path element, if none of those match it is left empty.  For a
+// local remote the directory is also checked for a .git, .hg, .svn or
+// .bzr dir or a bare git repo (HEAD, objects and refs), so a local remote
+// that isn't there (or isn't a checkout) may have no VCS.
+func ParseVCS(remote string) (*VCSRemote, error) {
+	u, err := Parse(remote)
+	if err != nil {
+		return nil, err
+	}
+	if u.Kind == KindURL && u.Opaque != "" {
+		return nil, fmt.Errorf("invalid VCS remote '%s': no repository path", remote)
+	}
+	p := u.Path
+	if u.Kind == KindWindowsPath || u.Kind == KindUNC {
+		p = strings.Replace(p, `\`, "/", -1)
+	}
+	p = strings.Trim(p, "/")
+	local := isLocalRemote(u)
+	r := &VCSRemote{URL: u, Host: u.Host, Port: u.Port, User: u.User}
+	if strings.HasSuffix(p, ".git") {
+		r.GitSuffix = true
+		p = strings.TrimSuffix(p, ".git")
+	}
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		r.Owner, r.Repo = p[:i], p[i+1:]
+	} else {
+		r.Repo = p
+	}
+	if local {
+		r.Owner = ""
+	}
+	if r.Repo == "" {
+		return nil, fmt.Errorf("invalid VCS remote '%s': no repository name", remote)
+	}
+	r.VCS = detectVCS(u, p, r.GitSuffix)
+	if r.VCS == "" && local {
+		r.VCS = localVCS(localDir(u))
+	}
+	return r, nil
+}
+
+// isLocalRemote checks for a local path or a file:// URL on this host
+func isLocalRemote(u *URL) bool {
+	if u.IsLocal() {
+		return true
+	}
+	return strings.EqualFold(u.Scheme, "file") && (u.Host == "" || strings.EqualFold(u.Host, "localhost"))
+}
+
+// localDir is the directory on disk for a local remote
+func localDir(u *URL) string {
+	if u.Kind == KindUNC {
+		return u.String()
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// localVCS looks at a local directory to see what VCS it belongs to, ""
+// if it can't tell (or it doesn't exist)
+func localVCS(dir string) string {
+	isDir := func(name string) bool {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		return err == nil && fi.IsDir()
+	}
+	for _, vcs := range []string{"git", "hg", "svn", "bzr"} {
+		if isDir("." + vcs) {
+			return vcs
+		}
+	}
+	if fi, err := os.Stat(filepath.Join(dir, "HEAD")); err == nil && !fi.IsDir() && isDir("objects") && isDir("refs") {
+		return "git"
+	}
+	return ""
+}
+
+// detectVCS guesses the VCS for a parsed remote from its form, see
+// ParseVCS()
+func detectVCS(u *URL, p string, gitSuffix bool) string {
+	if vcs, ok := vcsSchemes[strings.ToLower(u.Scheme)]; ok {
+		return vcs
+	}
+	if gitSuffix || u.Kind == KindSCP {
+		return "git"
+	}
+	host := strings.ToLower(u.Host)
+	if vcs, ok := vcsHosts[host]; ok {
+		return vcs
+	}
+	for _, vcs := range []string{"git", "hg", "svn", "bzr"} {
+		if strings.HasPrefix(host, vcs+".") {
+			return vcs
+		}
+		for _, elem := range strings.Split(p, "/") {
+			if elem == vcs {
+				return vcs
+			}
+		}
+	}
+	return ""
+}
+
+// FullName returns "owner/repo" or just the repo if there is no owner
+func (r *VCSRemote) FullName() string {
+	if r.Owner == "" {
+		return r.Repo
+	}
+	return r.Owner + "/" + r.Repo
+}
+
+// repoPath is the full name with the ".git" suffix put back if it had one
+func (r *VCSRemote) repoPath() string {
+	if r.GitSuffix {
+		return r.FullName() + ".git"
+	}
+	return r.FullName()
+}
+
+// SSH returns the remote as an ssh URL: scp style "git@host:owner/repo.git"
+// for git (or ssh://git@host:port/... if a non-default ssh port is in use)
+// and ssh://, svn+ssh:// or bzr+ssh:// for the others.  The user is kept
+// if the remote was already ssh, otherwise git uses "git" and hg "hg".
+func (r *VCSRemote) SSH() (string, error) {
+	if r.URL.IsLocal() || r.Host == "" {
+		return "", fmt.Errorf("cannot convert local VCS remote '%s' to ssh", r.URL)
+	}
+	isSSH := r.URL.Kind == KindSCP || strings.Contains(strings.ToLower(r.URL.Scheme), "ssh")
+	user, port := "", ""
+	if isSSH {
+		user, port = r.User, r.Port
+		if port == defaultPorts["ssh"] {
+			port = ""
+		}
+	}
+	if user == "" && (r.VCS == "git" || r.VCS == "" || r.VCS == "hg") {
+		user = "git"
+		if r.VCS == "hg" {
+			user = "hg"
+		}
+	}
+	scheme := "ssh"
+	switch r.VCS {
+	case "git", "":
+		if port == "" {
+			u := &URL{Kind: KindSCP, User: user, Host: r.Host, Path: r.repoPath()}
+			return u.String(), nil
+		}
+	case "svn", "bzr":
+		scheme = r.VCS + "+ssh"
+	}
+	u := &URL{Kind: KindURL, Scheme: scheme, User: user, Host: r.Host, Port: port, Path: "/" + r.repoPath()}
+	return u.String(), nil
+}
+
+// HTTPS returns the remote as "https://host/owner/repo[.git]", any user or
+// (ssh) port is dropped unless the remote was already http(s)
+func (r *VCSRemote) HTTPS() (string, error) {
+	if r.URL.IsLocal() || r.Host == "" {
+		return "", fmt.Errorf("cannot convert local VCS remote '%s' to https", r.URL)
+	}
+	port := ""
+	if s := strings.ToLower(r.URL.Scheme); (s == "http" || s == "https") && r.Port != defaultPorts[s] {
+		port = r.Port
+	}
+	u := &URL{Kind: KindURL, Scheme: "https", Host: r.Host, Port: port, Path: "/" + r.repoPath()}
+	return u.String(), nil
+}
+
+// ToSSH converts a VCS remote to its ssh form, see VCSRemote.SSH()
+func ToSSH(remote string) (string, error) {
+	r, err := ParseVCS(remote)
+	if err != nil {
+		return "", err
+	}
+	return r.SSH()
+}
+
+// ToHTTPS converts a VCS remote to its https form, see VCSRemote.HTTPS()
+func ToHTTPS(remote string) (string, error) {
+	r, err := ParseVCS(remote)
+	if err != nil {
+		return "", err
+	}
+	return r.HTTPS()
+}
@@ -0,0 +1,139 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package url
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestParseVCS checks the host/owner/repo split and VCS detection
+func TestParseVCS(t *testing.T) {
+	tests := []struct {
+		remote                       string
+		vcs, host, port, owner, repo string
+		gitSuffix                    bool
+	}{
+		{"https://github.com/dvln/dvln.git", "git", "github.com", "", "dvln", "dvln", true},
+		{"https://github.com/dvln/dvln", "git", "github.com", "", "dvln", "dvln", false},
+		{"git@github.com:dvln/util.git", "git", "github.com", "", "dvln", "util", true},
+		{"ssh://git@gitlab.example.com:2222/group/sub/proj.git", "git", "gitlab.example.com", "2222", "group/sub", "proj", true},
+		{"git://git.kernel.org/pub/scm/git/git.git", "git", "git.kernel.org", "", "pub/scm/git", "git", true},
+		{"file:///srv/repos/tool.git", "git", "", "", "", "tool", true},
+		{"/srv/repos/tool", "", "", "", "", "tool", false},
+		{"C:\\repos\\tool.git", "git", "", "", "", "tool", true},
+		{"ssh://hg@hg.example.org/proj", "hg", "hg.example.org", "", "", "proj", false},
+		{"hg+ssh://hg.example.org/team/proj", "hg", "hg.example.org", "", "team", "proj", false},
+		{"svn+ssh://svn.example.org/repos/proj/trunk/", "svn", "svn.example.org", "", "repos/proj", "trunk", false},
+		{"https://example.org/svn/proj", "svn", "example.org", "", "svn", "proj", false},
+		{"bzr+ssh://bazaar.launchpad.net/~dev/proj/trunk", "bzr", "bazaar.launchpad.net", "", "~dev/proj", "trunk", false},
+		{"https://code.launchpad.net/proj", "bzr", "code.launchpad.net", "", "", "proj", false},
+	}
+	for _, test := range tests {
+		r, err := ParseVCS(test.remote)
+		if err != nil {
+			t.Fatalf("ParseVCS(%s) failed: %s", test.remote, err)
+		}
+		if r.VCS != test.vcs || r.Host != test.host || r.Port != test.port || r.Owner != test.owner || r.Repo != test.repo || r.GitSuffix != test.gitSuffix {
+			t.Fatalf("ParseVCS(%s): unexpected result: %+v", test.remote, *r)
+		}
+	}
+	if r, _ := ParseVCS("git@github.com:dvln/util.git"); r.User != "git" || r.FullName() != "dvln/util" {
+		t.Fatalf("ParseVCS: expected user git and name dvln/util, was: %s and %s", r.User, r.FullName())
+	}
+	for _, bad := range []string{"", "https://github.com/", "mailto:dev@dvln.org"} {
+		if _, err := ParseVCS(bad); err == nil {
+			t.Fatalf("ParseVCS(%q): expected an error", bad)
+		}
+	}
+}
+
+// TestParseVCSLocal checks local remotes get their VCS from the directory
+func TestParseVCSLocal(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "dvln-util-url-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	mkdirs := func(dirs ...string) {
+		for _, dir := range dirs {
+			if err := os.MkdirAll(filepath.Join(tmp, dir), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	mkdirs("clone/.git", "work/.hg", "bare/objects", "bare/refs", "plain")
+	if err := ioutil.WriteFile(filepath.Join(tmp, "bare", "HEAD"), []byte("ref: refs/heads/main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		remote, vcs, repo string
+	}{
+		{filepath.Join(tmp, "clone"), "git", "clone"},
+		{filepath.Join(tmp, "work"), "hg", "work"},
+		{filepath.Join(tmp, "bare"), "git", "bare"},
+		{filepath.Join(tmp, "plain"), "", "plain"},
+		{"file://" + filepath.ToSlash(filepath.Join(tmp, "bare")), "git", "bare"},
+	}
+	for _, test := range tests {
+		r, err := ParseVCS(test.remote)
+		if err != nil {
+			t.Fatalf("ParseVCS(%s) failed: %s", test.remote, err)
+		}
+		if r.VCS != test.vcs || r.Owner != "" || r.Repo != test.repo {
+			t.Fatalf("ParseVCS(%s): unexpected result: %+v", test.remote, *r)
+		}
+	}
+}
+
+// TestToSSHAndHTTPS checks converting remotes between the ssh and https forms
+func TestToSSHAndHTTPS(t *testing.T) {
+	tests := []struct {
+		remote, ssh, https string
+	}{
+		{"https://github.com/dvln/dvln.git", "git@github.com:dvln/dvln.git", "https://github.com/dvln/dvln.git"},
+		{"https://github.com/dvln/dvln", "git@github.com:dvln/dvln", "https://github.com/dvln/dvln"},
+		{"git@github.com:dvln/util.git", "git@github.com:dvln/util.git", "https://github.com/dvln/util.git"},
+		{"ssh://deploy@git.example.org:2222/team/proj.git", "ssh://deploy@git.example.org:2222/team/proj.git", "https://git.example.org/team/proj.git"},
+		{"ssh://git@github.com:22/dvln/dvln.git", "git@github.com:dvln/dvln.git", "https://github.com/dvln/dvln.git"},
+		{"git://git.kernel.org/pub/scm/git/git.git", "git@git.kernel.org:pub/scm/git/git.git", "https://git.kernel.org/pub/scm/git/git.git"},
+		{"http://git.example.org:8080/proj.git", "git@git.example.org:proj.git", "https://git.example.org:8080/proj.git"},
+		{"https://hg.example.org/team/proj", "ssh://hg@hg.example.org/team/proj", "https://hg.example.org/team/proj"},
+		{"https://svn.example.org/repos/proj", "svn+ssh://svn.example.org/repos/proj", "https://svn.example.org/repos/proj"},
+	}
+	for _, test := range tests {
+		ssh, err := ToSSH(test.remote)
+		if err != nil {
+			t.Fatalf("ToSSH(%s) failed: %s", test.remote, err)
+		}
+		if ssh != test.ssh {
+			t.Fatalf("ToSSH(%s): expected %s, was: %s", test.remote, test.ssh, ssh)
+		}
+		https, err := ToHTTPS(test.remote)
+		if err != nil {
+			t.Fatalf("ToHTTPS(%s) failed: %s", test.remote, err)
+		}
+		if https != test.https {
+			t.Fatalf("ToHTTPS(%s): expected %s, was: %s", test.remote, test.https, https)
+		}
+	}
+	for _, local := range []string{"/srv/repos/tool.git", "file:///srv/repos/tool.git", "C:\\repos\\tool"} {
+		if _, err := ToSSH(local); err == nil {
+			t.Fatalf("ToSSH(%s): expected an error for a local remote", local)
+		}
+		if _, err := ToHTTPS(local); err == nil {
+			t.Fatalf("ToHTTPS(%s): expected an error for a local remote", local)
+		}
+	}
+}
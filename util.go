@@ -22,6 +22,7 @@
 //   util/system - routines for common system level examination
 //   util/unit - unit conversion utility routines (to human format, from human format)
 //   util/gotype - routines around manipulating/searching maps, slices, etc
+//   util/progress - progress reporting (bar, rate, ETA) for readers/writers
 // Right now these are independent packages, but all versioned within the single
 // repo named 'github.com/dvln/util'
 package util